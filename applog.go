@@ -7,7 +7,8 @@ import (
 )
 
 // Applog allows you to easily log to a different file to avoid the
-// clutter of the system log when debugging.
+// clutter of the system log when debugging.  To log to the same kind
+// of file using log/slog, see [AppLogHandler].
 func Applog(fname string, format string, a ...any) error {
 	var err error
 	var f *os.File
@@ -23,7 +24,7 @@ func Applog(fname string, format string, a ...any) error {
 
 	// Write the log message.
 	msg = fmt.Sprintf(format, a...)
-	timestamp = time.Now().Format("2006-01-02T15:04:05-07:00")
+	timestamp = time.Now().Format(applogTimestampFormat)
 	_, err = f.WriteString(fmt.Sprintf("%v: %v\n", timestamp, msg))
 
 	return err
@@ -0,0 +1,237 @@
+package jrutil
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strconv"
+	"time"
+	"unicode"
+)
+
+// applogTimestampFormat is the layout of the timestamp at the start
+// of each line written by [Applog()] and [AppLogHandler].
+const applogTimestampFormat = "2006-01-02T15:04:05-07:00"
+
+// AppLogHandler is a [slog.Handler] that writes to a file using the
+// same "timestamp: [LEVEL] message" layout as [AppLogger.Logf()]
+// followed by the attributes as space separated key=value pairs.
+// Attributes in groups have keys qualified by the group names like
+// "req.id=7".  If the AppLogger uses the AppLogJSON format, each
+// record is written as a JSON object instead.  Unlike Applog(), the
+// file is kept open by an [AppLogger] until Close() is called.  It is
+// safe for concurrent use by multiple goroutines.
+type AppLogHandler struct {
+	out    *AppLogger
+	opts   slog.HandlerOptions
+	attrs  []byte              // preformatted attributes from WithAttrs()
+	fields []appLogGroupedAttr // attributes from WithAttrs() for JSON
+	groups []string            // groups from WithGroup()
+}
+
+// appLogGroupedAttr is an attribute along with the groups it is in.
+type appLogGroupedAttr struct {
+	groups []string
+	attr   slog.Attr
+}
+
+// NewAppLogHandler returns a new AppLogHandler that appends to the
+// file named path creating it if necessary.  If opts is nil, the
+// default options are used which only logs messages at the minimum
+// level of the underlying [AppLogger] or above.  Use slog.New() to
+// create a logger that uses the handler, and call Close() when done
+// logging.  To share a file with an existing AppLogger, call
+// [AppLogger.Handler()] instead.
+func NewAppLogHandler(path string, opts *slog.HandlerOptions) (*AppLogHandler, error) {
+	l, err := NewAppLogger(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return l.Handler(opts), nil
+}
+
+// Close closes the underlying AppLogger.  Because handlers returned
+// by WithAttrs() and WithGroup() share the AppLogger, they must not be
+// used afterwards.
+func (h *AppLogHandler) Close() error {
+	return h.out.Close()
+}
+
+// Enabled returns true if messages at the given level should be
+// logged.  If the handler options do not set a level, the minimum
+// level of the underlying AppLogger is used.
+func (h *AppLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if h.opts.Level != nil {
+		return level >= h.opts.Level.Level()
+	}
+	return h.out.Enabled("", level)
+}
+
+// Handle writes the record to the log file as a single line.
+func (h *AppLogHandler) Handle(_ context.Context, r slog.Record) error {
+	timestamp := r.Time
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	if h.out.opts.Format == AppLogJSON {
+		return h.handleJSON(timestamp, r)
+	}
+
+	// Format the line with the same prefix as AppLogger.Logf().
+	buf := appendAppLogText(make([]byte, 0, 256), &AppLogEntry{
+		Time:     timestamp,
+		Level:    r.Level,
+		HasLevel: true,
+		Message:  r.Message,
+	})
+	if h.opts.AddSource && r.PC != 0 {
+		frames := runtime.CallersFrames([]uintptr{r.PC})
+		frame, _ := frames.Next()
+		source := fmt.Sprintf("%v:%v", frame.File, frame.Line)
+		buf = appendAppLogAttr(buf, nil, slog.String(slog.SourceKey, source), h.opts.ReplaceAttr)
+	}
+	buf = append(buf, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		buf = appendAppLogAttr(buf, h.groups, a, h.opts.ReplaceAttr)
+		return true
+	})
+	buf = append(buf, '\n')
+
+	return h.out.writeEntry(buf)
+}
+
+// handleJSON writes the record to the log file as a JSON object.
+func (h *AppLogHandler) handleJSON(timestamp time.Time, r slog.Record) error {
+	e := AppLogEntry{
+		Time:     timestamp,
+		Level:    r.Level,
+		HasLevel: true,
+		Message:  r.Message,
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		frames := runtime.CallersFrames([]uintptr{r.PC})
+		frame, _ := frames.Next()
+		e.Caller = formatAppLogCaller(frame.File, frame.Line)
+	}
+	for _, ga := range h.fields {
+		e.Fields = addAppLogField(e.Fields, ga.groups, ga.attr, h.opts.ReplaceAttr)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		e.Fields = addAppLogField(e.Fields, h.groups, a, h.opts.ReplaceAttr)
+		return true
+	})
+	buf, err := appendAppLogJSON(make([]byte, 0, 256), &e)
+	if err != nil {
+		return err
+	}
+	return h.out.writeEntry(buf)
+}
+
+// WithAttrs returns a new handler that adds attrs to every line.
+func (h *AppLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.attrs = slices.Clip(h.attrs)
+	h2.fields = slices.Clip(h.fields)
+	for _, a := range attrs {
+		h2.attrs = appendAppLogAttr(h2.attrs, h.groups, a, h.opts.ReplaceAttr)
+		h2.fields = append(h2.fields, appLogGroupedAttr{groups: h.groups, attr: a})
+	}
+	return &h2
+}
+
+// WithGroup returns a new handler that qualifies the keys of the
+// attributes that follow with name.
+func (h *AppLogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(slices.Clip(h.groups), name)
+	return &h2
+}
+
+// resolveAppLogAttr resolves the value of a and applies replace if it
+// is not nil and a is not a group.
+func resolveAppLogAttr(
+	groups []string,
+	a slog.Attr,
+	replace func([]string, slog.Attr) slog.Attr,
+) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if replace != nil && a.Value.Kind() != slog.KindGroup {
+		a = replace(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	return a
+}
+
+// appendAppLogAttr appends " key=value" for a to buf qualifying the
+// key with the names in groups.  If replace is not nil, it is applied
+// to a like slog.HandlerOptions.ReplaceAttr.
+func appendAppLogAttr(
+	buf []byte,
+	groups []string,
+	a slog.Attr,
+	replace func([]string, slog.Attr) slog.Attr,
+) []byte {
+	a = resolveAppLogAttr(groups, a, replace)
+	if a.Equal(slog.Attr{}) {
+		return buf
+	}
+
+	// Flatten groups.  A group with an empty key is inlined.
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return buf
+		}
+		if a.Key != "" {
+			groups = append(slices.Clip(groups), a.Key)
+		}
+		for _, ga := range attrs {
+			buf = appendAppLogAttr(buf, groups, ga, replace)
+		}
+		return buf
+	}
+
+	// Append the key.
+	buf = append(buf, ' ')
+	for _, g := range groups {
+		buf = append(buf, g...)
+		buf = append(buf, '.')
+	}
+	buf = append(buf, a.Key...)
+	buf = append(buf, '=')
+
+	// Append the value.
+	var s string
+	switch a.Value.Kind() {
+	case slog.KindTime:
+		s = a.Value.Time().Format(time.RFC3339Nano)
+	default:
+		s = a.Value.String()
+	}
+	if needsAppLogQuoting(s) {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+// needsAppLogQuoting returns true if s must be quoted to be read back
+// unambiguously as a value.
+func needsAppLogQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r == '=' || r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
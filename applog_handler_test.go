@@ -0,0 +1,135 @@
+package jrutil
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// readAppLogLines returns the lines of the log file with the
+// timestamps removed after checking that each line has one.
+func readAppLogLines(t *testing.T, path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	re := regexp.MustCompile(`^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d[-+]\d\d:\d\d: `)
+	var lines []string
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		loc := re.FindStringIndex(line)
+		if loc == nil {
+			t.Fatalf("readAppLogLines(%q): missing timestamp: %q", path, line)
+		}
+		lines = append(lines, strings.TrimSuffix(line[loc[1]:], "\n"))
+	}
+	return lines
+}
+
+func TestAppLogHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	h, err := NewAppLogHandler(path, nil)
+	if err != nil {
+		t.Fatalf("NewAppLogHandler: %v", err)
+	}
+	logger := slog.New(h)
+	logger.Info("hello", "name", "World", "n", 7)
+	logger.Debug("not logged")
+	logger.With("id", 42).WithGroup("req").Warn("grouped",
+		"path", "/a b", slog.Group("user", "name", "bob"))
+	logger.Info("empty", "s", "", slog.Group("g"),
+		slog.Time("t", time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)))
+	err = h.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	actual := readAppLogLines(t, path)
+	expected := []string{
+		"[INFO] hello name=World n=7",
+		`[WARN] grouped id=42 req.path="/a b" req.user.name=bob`,
+		`[INFO] empty s="" t=2024-01-02T03:04:05.000000006Z`,
+	}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("AppLogHandler: expected=%q  actual=%q", expected, actual)
+	}
+}
+
+func TestAppLogHandlerOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	h, err := NewAppLogHandler(path, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == "secret" {
+				return slog.String(a.Key, "***")
+			}
+			return a
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewAppLogHandler: %v", err)
+	}
+	logger := slog.New(h)
+	logger.Debug("debug", "secret", "hunter2")
+	err = h.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	actual := readAppLogLines(t, path)
+	expected := []string{"[DEBUG] debug secret=***"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("AppLogHandler: expected=%q  actual=%q", expected, actual)
+	}
+}
+
+func TestAppLogHandlerConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	h, err := NewAppLogHandler(path, nil)
+	if err != nil {
+		t.Fatalf("NewAppLogHandler: %v", err)
+	}
+	logger := slog.New(h)
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l := logger.With("g", g)
+			for i := 0; i < 100; i++ {
+				l.Info("message", "i", i)
+			}
+		}()
+	}
+	wg.Wait()
+	err = h.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	actual := readAppLogLines(t, path)
+	if len(actual) != 1000 {
+		t.Fatalf("AppLogHandler: expected_length=%v  actual_length=%v",
+			1000, len(actual))
+	}
+	seen := map[string]bool{}
+	for _, line := range actual {
+		seen[line] = true
+	}
+	for g := 0; g < 10; g++ {
+		for i := 0; i < 100; i++ {
+			line := fmt.Sprintf("[INFO] message g=%v i=%v", g, i)
+			if !seen[line] {
+				t.Fatalf("AppLogHandler: missing line %q", line)
+			}
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package jrutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AppLogFormat is the format of the entries written by an
+// [AppLogger].
+type AppLogFormat int
+
+const (
+	// AppLogText writes each entry as "timestamp: message" like
+	// [Applog()] with the level, component, and fields added when
+	// present.
+	AppLogText AppLogFormat = iota
+
+	// AppLogJSON writes each entry as a single line holding a JSON
+	// object, also known as JSON Lines.  The object has the following
+	// members where the ones marked as optional are omitted when
+	// empty:
+	//
+	//	time       timestamp in RFC 3339 format with nanoseconds
+	//	level      level like "INFO" (optional)
+	//	component  component name (optional)
+	//	msg        message
+	//	caller     file:line of the caller (optional)
+	//	fields     object holding the structured fields (optional)
+	AppLogJSON
+)
+
+// ErrAppLogEntry is returned, possibly wrapped, by
+// [ParseAppLogEntry()] when the entry cannot be parsed.
+var ErrAppLogEntry = errors.New("invalid app log entry")
+
+// AppLogEntry is an entry written by [Applog()] or an [AppLogger] as
+// returned by [ParseAppLogEntry()].
+type AppLogEntry struct {
+
+	// Time is when the entry was written.
+	Time time.Time
+
+	// Level is the level of the entry if HasLevel is true.
+	Level slog.Level
+
+	// HasLevel is false for entries written without a level by
+	// Applog() or AppLogger.Printf().
+	HasLevel bool
+
+	// Component is the name of the component or empty.
+	Component string
+
+	// Message is the message.
+	Message string
+
+	// Caller is the base name of the source file and the line number
+	// of the code that wrote the entry like "main.go:42".  It is only
+	// recorded in the AppLogJSON format.
+	Caller string
+
+	// Fields holds the structured fields.  They are only recovered
+	// from the AppLogJSON format where groups are nested maps and
+	// numbers are float64 values.
+	Fields map[string]any
+}
+
+// appLogJSONEntry is the JSON representation of an AppLogEntry.
+type appLogJSONEntry struct {
+	Time      string         `json:"time"`
+	Level     string         `json:"level,omitempty"`
+	Component string         `json:"component,omitempty"`
+	Msg       string         `json:"msg"`
+	Caller    string         `json:"caller,omitempty"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+// appendAppLogJSON appends e as a JSON object followed by "\n" to buf.
+func appendAppLogJSON(buf []byte, e *AppLogEntry) ([]byte, error) {
+	je := appLogJSONEntry{
+		Time:      e.Time.Format(time.RFC3339Nano),
+		Component: e.Component,
+		Msg:       e.Message,
+		Caller:    e.Caller,
+		Fields:    e.Fields,
+	}
+	if e.HasLevel {
+		je.Level = e.Level.String()
+	}
+	data, err := json.Marshal(&je)
+	if err != nil {
+		return buf, err
+	}
+	buf = append(buf, data...)
+	return append(buf, '\n'), nil
+}
+
+// appendAppLogText appends e, except for its fields, in the
+// AppLogText format without the trailing "\n" to buf.
+func appendAppLogText(buf []byte, e *AppLogEntry) []byte {
+	buf = e.Time.AppendFormat(buf, applogTimestampFormat)
+	buf = append(buf, ": "...)
+	if e.HasLevel {
+		buf = append(buf, '[')
+		buf = append(buf, e.Level.String()...)
+		buf = append(buf, "] "...)
+	}
+	if e.Component != "" {
+		buf = append(buf, e.Component...)
+		buf = append(buf, ": "...)
+	}
+	return append(buf, e.Message...)
+}
+
+// addAppLogField adds a to fields inside the nested maps named by
+// groups and returns fields which is allocated if it is nil.  If
+// replace is not nil, it is applied to a like
+// slog.HandlerOptions.ReplaceAttr.
+func addAppLogField(
+	fields map[string]any,
+	groups []string,
+	a slog.Attr,
+	replace func([]string, slog.Attr) slog.Attr,
+) map[string]any {
+	a = resolveAppLogAttr(groups, a, replace)
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	// Add the members of groups.  A group with an empty key is
+	// inlined.
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range attrs {
+			fields = addAppLogField(fields, groups, ga, replace)
+		}
+		return fields
+	}
+
+	// Find or create the map for the innermost group.
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	m := fields
+	for _, g := range groups {
+		sub, ok := m[g].(map[string]any)
+		if !ok {
+			sub = map[string]any{}
+			m[g] = sub
+		}
+		m = sub
+	}
+	m[a.Key] = appLogJSONValue(a.Value)
+
+	return fields
+}
+
+// appLogJSONValue returns v as a value that can be marshaled to JSON.
+func appLogJSONValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindFloat64:
+		f := v.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		return f
+	case slog.KindBool:
+		return v.Bool()
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	}
+
+	// Errors usually have no exported fields, so use their messages.
+	if err, ok := v.Any().(error); ok {
+		return err.Error()
+	}
+	return appLogJSONAny{v.Any()}
+}
+
+// appLogJSONAny is an arbitrary value that is marshaled as-is if
+// possible and formatted as a string otherwise, like a channel or a
+// function, so one bad value does not prevent the entry from being
+// written.
+type appLogJSONAny struct {
+	v any
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a appLogJSONAny) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(a.v)
+	if err != nil {
+		return json.Marshal(fmt.Sprint(a.v))
+	}
+	return data, nil
+}
+
+// formatAppLogCaller returns "file:line" using the base name of file.
+func formatAppLogCaller(file string, line int) string {
+	return fmt.Sprintf("%v:%v", filepath.Base(file), line)
+}
+
+// appLogCaller returns "file:line" for the function skip frames above
+// the caller of appLogCaller or the empty string if it is not known.
+func appLogCaller(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	return formatAppLogCaller(file, line)
+}
+
+// ParseAppLogEntry parses one line written by [Applog()] or an
+// [AppLogger] in either the AppLogText or AppLogJSON format.  Lines
+// starting with '{' are parsed as JSON.  The EOL sequence, if any, is
+// ignored.  If the line cannot be parsed, the returned error wraps
+// [ErrAppLogEntry].
+//
+// Because the text format is meant for people, parsing it is
+// ambiguous: a component is only recognized for entries with a level
+// when the message starts with a word without spaces followed by
+// ": ", and key=value fields are left in the message.  Use the JSON
+// format when entries must be read back exactly.
+func ParseAppLogEntry(line string) (*AppLogEntry, error) {
+	line = StripEOL(line)
+	if strings.HasPrefix(line, "{") {
+		return parseAppLogJSON(line)
+	}
+	return parseAppLogText(line)
+}
+
+// parseAppLogJSON parses an entry in the AppLogJSON format.
+func parseAppLogJSON(line string) (*AppLogEntry, error) {
+	var je appLogJSONEntry
+	err := json.Unmarshal([]byte(line), &je)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAppLogEntry, err)
+	}
+	e := &AppLogEntry{
+		Component: je.Component,
+		Message:   je.Msg,
+		Caller:    je.Caller,
+		Fields:    je.Fields,
+	}
+	e.Time, err = time.Parse(time.RFC3339Nano, je.Time)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAppLogEntry, err)
+	}
+	if je.Level != "" {
+		err = e.Level.UnmarshalText([]byte(je.Level))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrAppLogEntry, err)
+		}
+		e.HasLevel = true
+	}
+	return e, nil
+}
+
+// parseAppLogText parses an entry in the AppLogText format.
+func parseAppLogText(line string) (*AppLogEntry, error) {
+	var err error
+	e := &AppLogEntry{}
+
+	// Parse the timestamp.
+	timestamp, rest, found := strings.Cut(line, ": ")
+	if !found {
+		return nil, fmt.Errorf("%w: missing timestamp", ErrAppLogEntry)
+	}
+	e.Time, err = time.Parse(applogTimestampFormat, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAppLogEntry, err)
+	}
+
+	// Parse the optional level and component.
+	if strings.HasPrefix(rest, "[") {
+		levelText, afterLevel, found := strings.Cut(rest[1:], "] ")
+		if found && e.Level.UnmarshalText([]byte(levelText)) == nil {
+			e.HasLevel = true
+			rest = afterLevel
+			component, afterComponent, found := strings.Cut(rest, ": ")
+			if found && component != "" && !strings.ContainsAny(component, " \t") {
+				e.Component = component
+				rest = afterComponent
+			}
+		}
+	}
+
+	e.Message = rest
+	return e, nil
+}
+
+// ReadAppLog reads the entries written by [Applog()] or an [AppLogger]
+// from r in either format, even mixed, and invokes fn for each one.
+// Empty lines are skipped.  To receive the next entry, fn must return
+// (true, nil).  Lines are read with [ForEachLineInfo()], so if an
+// entry cannot be parsed or fn returns an error, the error is wrapped
+// in a *[LineError] that says which line failed.
+func ReadAppLog(r io.Reader, fn func(*AppLogEntry) (bool, error)) error {
+	return ForEachLineInfo(r, true, func(line string, info LineInfo) (bool, error) {
+		if line == "" {
+			return true, nil
+		}
+		e, err := ParseAppLogEntry(line)
+		if err != nil {
+			return false, err
+		}
+		return fn(e)
+	})
+}
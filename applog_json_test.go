@@ -0,0 +1,280 @@
+package jrutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readAppLogEntries reads the entries of the log file with ReadAppLog().
+func readAppLogEntries(t *testing.T, path string) []*AppLogEntry {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+	var entries []*AppLogEntry
+	err = ReadAppLog(f, func(e *AppLogEntry) (bool, error) {
+		entries = append(entries, e)
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("ReadAppLog(%q): %v", path, err)
+	}
+	return entries
+}
+
+// appLogTestCaller is the expected caller of entries logged by this
+// file.  Only the file name is checked because the line numbers change
+// whenever the file is edited.
+const appLogTestCaller = "applog_json_test.go:"
+
+// checkAppLogEntries checks that the entries match the expected
+// entries except for their times, which must be between start and now,
+// and callers, which only need to start with the expected caller.
+func checkAppLogEntries(
+	t *testing.T,
+	entries []*AppLogEntry,
+	expected []AppLogEntry,
+	start time.Time,
+) {
+	if len(entries) != len(expected) {
+		t.Fatalf("ReadAppLog: expected_length=%v  actual_length=%v",
+			len(expected), len(entries))
+	}
+	for i, e := range entries {
+		if e.Time.Before(start.Add(-time.Second)) || e.Time.After(time.Now()) {
+			t.Errorf("ReadAppLog(entry=%v): expected_time>=%v  actual_time=%v",
+				i, start, e.Time)
+		}
+		actual := *e
+		actual.Time = time.Time{}
+		if expected[i].Caller != "" && strings.HasPrefix(e.Caller, expected[i].Caller) {
+			actual.Caller = expected[i].Caller
+		}
+		if fmt.Sprintf("%+v", actual) != fmt.Sprintf("%+v", expected[i]) {
+			t.Errorf("ReadAppLog(entry=%v): expected=%+v  actual=%+v",
+				i, expected[i], actual)
+		}
+	}
+}
+
+func TestAppLoggerJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewAppLogger(path, &AppLoggerOptions{Format: AppLogJSON})
+	if err != nil {
+		t.Fatalf("NewAppLogger: %v", err)
+	}
+	start := time.Now()
+	err = l.Printf("plain %v", 1)
+	if err != nil {
+		t.Fatalf("Printf: %v", err)
+	}
+	err = l.Component("db").Warnf("slow query")
+	if err != nil {
+		t.Fatalf("Warnf: %v", err)
+	}
+	err = l.Log(slog.LevelError, "failed", "n", 3, slog.Group("req", "id", "x y"))
+	if err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+	slog.New(l.Handler(nil)).With("a", true).WithGroup("g").Info("from slog", "b", 2.5)
+	slog.New(l.Handler(&slog.HandlerOptions{AddSource: true})).Info("with source")
+	err = l.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// The handler only records the caller if AddSource is set.
+	expected := []AppLogEntry{
+		{Message: "plain 1", Caller: appLogTestCaller},
+		{
+			Level:     slog.LevelWarn,
+			HasLevel:  true,
+			Component: "db",
+			Message:   "slow query",
+			Caller:    appLogTestCaller,
+		},
+		{
+			Level:    slog.LevelError,
+			HasLevel: true,
+			Message:  "failed",
+			Caller:   appLogTestCaller,
+			Fields: map[string]any{
+				"n":   3.0,
+				"req": map[string]any{"id": "x y"},
+			},
+		},
+		{
+			Level:    slog.LevelInfo,
+			HasLevel: true,
+			Message:  "from slog",
+			Fields: map[string]any{
+				"a": true,
+				"g": map[string]any{"b": 2.5},
+			},
+		},
+		{
+			Level:    slog.LevelInfo,
+			HasLevel: true,
+			Message:  "with source",
+			Caller:   appLogTestCaller,
+		},
+	}
+	checkAppLogEntries(t, readAppLogEntries(t, path), expected, start)
+}
+
+// appLogTestMarshaler marshals itself as a fixed JSON object.
+type appLogTestMarshaler struct{}
+
+func (appLogTestMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`{"custom":1}`), nil
+}
+
+// TestAppLoggerJSONValues tests values that cannot be marshaled as-is.
+func TestAppLoggerJSONValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewAppLogger(path, &AppLoggerOptions{Format: AppLogJSON})
+	if err != nil {
+		t.Fatalf("NewAppLogger: %v", err)
+	}
+	start := time.Now()
+	ch := make(chan int)
+	err = l.Log(slog.LevelInfo, "values",
+		"err", errors.New("boom"),
+		"ch", ch,
+		"nested", []any{1, ch},
+		"custom", appLogTestMarshaler{},
+		"dur", 1500*time.Millisecond,
+	)
+	if err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+	err = l.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	expected := []AppLogEntry{
+		{
+			Level:    slog.LevelInfo,
+			HasLevel: true,
+			Message:  "values",
+			Caller:   appLogTestCaller,
+			Fields: map[string]any{
+				"err":    "boom",
+				"ch":     fmt.Sprint(ch),
+				"nested": fmt.Sprint([]any{1, ch}),
+				"custom": map[string]any{"custom": 1.0},
+				"dur":    "1.5s",
+			},
+		},
+	}
+	checkAppLogEntries(t, readAppLogEntries(t, path), expected, start)
+}
+
+func TestAppLoggerTextRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewAppLogger(path, nil)
+	if err != nil {
+		t.Fatalf("NewAppLogger: %v", err)
+	}
+	start := time.Now()
+	err = l.Printf("plain: %v", 1)
+	if err != nil {
+		t.Fatalf("Printf: %v", err)
+	}
+	err = l.Infof("no component")
+	if err != nil {
+		t.Fatalf("Infof: %v", err)
+	}
+	err = l.Component("http").Log(slog.LevelWarn, "slow", "ms", 120)
+	if err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+	err = l.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// The timestamps in the text format only have second precision.
+	expected := []AppLogEntry{
+		{Message: "plain: 1"},
+		{HasLevel: true, Level: slog.LevelInfo, Message: "no component"},
+		{HasLevel: true, Level: slog.LevelWarn, Component: "http", Message: "slow ms=120"},
+	}
+	start = start.Truncate(time.Second)
+	checkAppLogEntries(t, readAppLogEntries(t, path), expected, start)
+}
+
+func TestParseAppLogEntry(t *testing.T) {
+	data := []struct {
+		line     string
+		expected AppLogEntry
+	}{
+		{
+			line: `{"time":"2024-01-02T03:04:05.123456789Z","level":"DEBUG+2",` +
+				`"msg":"m","caller":"x.go:9"}` + "\r\n",
+			expected: AppLogEntry{
+				Time:     time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC),
+				Level:    slog.LevelDebug + 2,
+				HasLevel: true,
+				Message:  "m",
+				Caller:   "x.go:9",
+			},
+		},
+		{
+			line: "2024-01-02T03:04:05-07:00: [bogus] message",
+			expected: AppLogEntry{
+				Time:    time.Date(2024, 1, 2, 10, 4, 5, 0, time.UTC),
+				Message: "[bogus] message",
+			},
+		},
+	}
+	for _, d := range data {
+		actual, err := ParseAppLogEntry(d.line)
+		if err != nil {
+			t.Fatalf("ParseAppLogEntry(%q): %v", d.line, err)
+		}
+		if !actual.Time.Equal(d.expected.Time) {
+			t.Errorf("ParseAppLogEntry(%q): expected_time=%v  actual_time=%v",
+				d.line, d.expected.Time, actual.Time)
+		}
+		actual.Time = d.expected.Time
+		if fmt.Sprintf("%+v", *actual) != fmt.Sprintf("%+v", d.expected) {
+			t.Errorf("ParseAppLogEntry(%q): expected=%+v  actual=%+v",
+				d.line, d.expected, *actual)
+		}
+	}
+
+	for _, line := range []string{
+		"no timestamp",
+		"yesterday: message",
+		`{"time":"2024"}`,
+		`{"time":"2024-01-02T03:04:05Z","level":"loud"}`,
+		`{"time":`,
+	} {
+		_, err := ParseAppLogEntry(line)
+		if !errors.Is(err, ErrAppLogEntry) {
+			t.Errorf("ParseAppLogEntry(%q): expected=%v  actual=%v",
+				line, ErrAppLogEntry, err)
+		}
+	}
+}
+
+func TestReadAppLogError(t *testing.T) {
+	text := "2024-01-02T03:04:05-07:00: one\n\nbad\n"
+	err := ReadAppLog(strings.NewReader(text), func(e *AppLogEntry) (bool, error) {
+		return true, nil
+	})
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Number != 3 || !errors.Is(err, ErrAppLogEntry) {
+		t.Errorf("ReadAppLog(%q): expected=%v on line 3  actual=%v",
+			text, ErrAppLogEntry, err)
+	}
+}
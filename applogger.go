@@ -0,0 +1,337 @@
+package jrutil
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// defaultAppLogFlushInterval is how often a buffered AppLogger
+// flushes its buffer by default.
+const defaultAppLogFlushInterval = time.Second
+
+// AppLoggerOptions are the options for [NewAppLogger()].
+type AppLoggerOptions struct {
+
+	// BufferSize is the size of the buffer used to batch writes to
+	// the file.  If zero, which is the default, each entry is written
+	// to the file immediately.
+	BufferSize int
+
+	// FlushInterval is how often the buffer is flushed when
+	// BufferSize is not zero.  The default is one second.
+	FlushInterval time.Duration
+
+	// MaxSize is the size in bytes the file is allowed to reach
+	// before it is rotated.  If zero, which is the default, the file
+	// is not rotated based on its size.  If rotating fails, entries
+	// are still written to the file, and rotating is retried after a
+	// minute.
+	MaxSize int64
+
+	// RotateEvery causes the file to be rotated at the start of
+	// every hour or day in local time.  The default is RotateNever.
+	RotateEvery AppLogRotation
+
+	// MaxBackups is the number of rotated files to keep.  If zero,
+	// which is the default, all rotated files are kept.
+	MaxBackups int
+
+	// TimestampSuffix causes rotated files to be named by appending
+	// the time of the rotation with nanosecond precision like
+	// "app.log.2006-01-02T15-04-05.000000000" instead of appending a
+	// number like "app.log.1" where the most recent rotated file is
+	// always ".1".
+	TimestampSuffix bool
+
+	// Compress causes rotated files to be compressed with gzip in the
+	// background which appends ".gz" to their names.
+	Compress bool
+
+	// ReopenOnSIGHUP causes the file to be reopened when the process
+	// receives SIGHUP which is how external tools like logrotate
+	// signal that they have moved the file.
+	ReopenOnSIGHUP bool
+
+	// Level is the minimum level of entries written by the leveled
+	// methods like Debugf().  The default is slog.LevelInfo.  It is
+	// overridden by the JRUTIL_APPLOG environment variable.  See
+	// [AppLogEnvVar].
+	Level slog.Level
+
+	// Format is the format of the entries.  The default is
+	// AppLogText.
+	Format AppLogFormat
+}
+
+// AppLogger is like [Applog()] except the file is opened once and kept
+// open which is much faster when logging frequently.  It is safe for
+// concurrent use by multiple goroutines, and each entry is written as
+// a whole line so entries from different goroutines never interleave.
+// The file can be rotated based on its size or the time of day.  See
+// [AppLoggerOptions] for details.
+type AppLogger struct {
+	mu     sync.Mutex
+	path   string
+	opts   AppLoggerOptions
+	f      *os.File
+	w      *bufio.Writer // nil if not buffering
+	size   int64         // size of the file including buffered entries
+	next   time.Time     // time of the next time-based rotation
+	retry  time.Time     // time to retry rotating after a failure
+	closed bool
+	stop   chan struct{}  // closed to stop the background goroutines
+	done   sync.WaitGroup // waits for the background goroutines
+	hup    chan os.Signal // receives SIGHUP if ReopenOnSIGHUP
+
+	// Rotated files that are being compressed and a counter used to
+	// give them unique names while they are.
+	pending    []*appLogPending
+	pendingSeq int
+
+	// Minimum levels which are not protected by mu so they can be
+	// checked without waiting for writes.
+	level   slog.LevelVar
+	levelMu sync.RWMutex
+	levels  map[string]slog.Level // per-component minimum levels
+
+	// Rotated files are compressed without holding mu, so errors are
+	// recorded using their own lock.
+	compressing sync.WaitGroup
+	errMu       sync.Mutex
+	bgErr       error // first error rotating or compressing a file
+}
+
+// NewAppLogger returns a new AppLogger that appends to the file named
+// path creating it if necessary.  If opts is nil, the default options
+// are used.  If the JRUTIL_APPLOG environment variable is set but
+// cannot be parsed, an [AppLogLevelError] is returned.  Call Close()
+// when done logging.
+func NewAppLogger(path string, opts *AppLoggerOptions) (*AppLogger, error) {
+	if opts == nil {
+		opts = &AppLoggerOptions{}
+	}
+	l := &AppLogger{
+		path: path,
+		opts: *opts,
+		stop: make(chan struct{}),
+	}
+	err := l.initLevels()
+	if err != nil {
+		return nil, err
+	}
+	err = l.open()
+	if err != nil {
+		return nil, err
+	}
+	l.next = l.opts.RotateEvery.next(time.Now())
+
+	// Start flushing the buffer periodically.
+	if opts.BufferSize > 0 {
+		interval := opts.FlushInterval
+		if interval <= 0 {
+			interval = defaultAppLogFlushInterval
+		}
+		l.done.Add(1)
+		go l.flushPeriodically(interval)
+	}
+
+	// Start watching for SIGHUP.
+	if opts.ReopenOnSIGHUP {
+		l.watchSIGHUP()
+	}
+
+	return l, nil
+}
+
+// open opens the file, or reopens it after it has been rotated, and
+// updates the size.  The caller must hold the lock unless l is new.
+func (l *AppLogger) open() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f = f
+	l.size = info.Size()
+	if l.opts.BufferSize > 0 {
+		if l.w == nil {
+			l.w = bufio.NewWriterSize(f, l.opts.BufferSize)
+		} else {
+			l.w.Reset(f)
+		}
+	}
+	return nil
+}
+
+// flushPeriodically flushes the buffer every interval until Close()
+// is called.
+func (l *AppLogger) flushPeriodically(interval time.Duration) {
+	defer l.done.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			// There is nowhere to report the error, but the next
+			// write or flush will return it because bufio.Writer
+			// errors are sticky.
+			_ = l.Flush()
+		}
+	}
+}
+
+// Printf writes a log entry using the same format as [Applog()].  The
+// entry does not have a level, so it is always written.
+func (l *AppLogger) Printf(format string, a ...any) error {
+	return l.log(1, false, 0, "", fmt.Sprintf(format, a...), nil)
+}
+
+// log formats and writes an entry.  For the AppLogJSON format, the
+// caller is the function skip frames above the caller of log.
+func (l *AppLogger) log(
+	skip int,
+	hasLevel bool,
+	level slog.Level,
+	component string,
+	msg string,
+	attrs []slog.Attr,
+) error {
+	e := AppLogEntry{
+		Time:      time.Now(),
+		Level:     level,
+		HasLevel:  hasLevel,
+		Component: component,
+		Message:   msg,
+	}
+	buf := make([]byte, 0, 256)
+	if l.opts.Format == AppLogJSON {
+		e.Caller = appLogCaller(skip + 1)
+		for _, a := range attrs {
+			e.Fields = addAppLogField(e.Fields, nil, a, nil)
+		}
+		var err error
+		buf, err = appendAppLogJSON(buf, &e)
+		if err != nil {
+			return err
+		}
+	} else {
+		buf = appendAppLogText(buf, &e)
+		for _, a := range attrs {
+			buf = appendAppLogAttr(buf, nil, a, nil)
+		}
+		buf = append(buf, '\n')
+	}
+	return l.writeEntry(buf)
+}
+
+// writeEntry writes one complete entry to the file.
+func (l *AppLogger) writeEntry(entry []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return os.ErrClosed
+	}
+
+	// Rotate the file if necessary.  If only renaming or removing the
+	// rotated files failed, still write the entry to the reopened
+	// file, report the error from Close(), and wait before trying
+	// again so a persistent failure does not fail every write.
+	if l.needsRotation(len(entry)) {
+		rotateErr, err := l.rotate()
+		if err != nil {
+			return err
+		}
+		if rotateErr != nil {
+			l.setBgErr(rotateErr)
+			l.retry = time.Now().Add(appLogRotateRetry)
+		}
+	}
+
+	var err error
+	var n int
+	if l.w != nil {
+		n, err = l.w.Write(entry)
+	} else {
+		n, err = l.f.Write(entry)
+	}
+	l.size += int64(n)
+	return err
+}
+
+// setBgErr records err to be returned by Close() unless an earlier
+// error was already recorded.
+func (l *AppLogger) setBgErr(err error) {
+	l.errMu.Lock()
+	if l.bgErr == nil {
+		l.bgErr = err
+	}
+	l.errMu.Unlock()
+}
+
+// Flush writes any buffered entries to the file.
+func (l *AppLogger) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return os.ErrClosed
+	}
+	if l.w == nil {
+		return nil
+	}
+	return l.w.Flush()
+}
+
+// Close flushes any buffered entries and closes the file.  It also
+// returns the first error, if any, from rotating or compressing files
+// that could not be returned by the write that caused it.
+func (l *AppLogger) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return os.ErrClosed
+	}
+	l.closed = true
+	l.mu.Unlock()
+
+	// Stop the background goroutines without holding the lock because
+	// they might be waiting for the lock.  This also waits for any
+	// rotated files to finish being compressed.
+	if l.hup != nil {
+		signal.Stop(l.hup)
+	}
+	close(l.stop)
+	l.done.Wait()
+	l.compressing.Wait()
+
+	err := l.bgErr
+	if l.w != nil {
+		if flushErr := l.w.Flush(); err == nil {
+			err = flushErr
+		}
+	}
+	if closeErr := l.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Handler returns a new [AppLogHandler] that writes to l.  If opts is
+// nil, the default options are used.
+func (l *AppLogger) Handler(opts *slog.HandlerOptions) *AppLogHandler {
+	h := &AppLogHandler{out: l}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
@@ -0,0 +1,235 @@
+package jrutil
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// AppLogEnvVar is the environment variable read by [NewAppLogger()]
+// to override the minimum levels.  It holds a comma separated list of
+// entries where "component=level" sets the minimum level for one
+// component and a bare "level" sets the minimum level for everything
+// else.  For example, "warn,db=debug,http=error".  Levels are parsed
+// by [slog.Level.UnmarshalText()] so they are case-insensitive and
+// can have offsets like "debug+2".
+const AppLogEnvVar = "JRUTIL_APPLOG"
+
+// AppLogLevelError is returned by [NewAppLogger()] when the
+// [AppLogEnvVar] environment variable cannot be parsed.
+type AppLogLevelError struct {
+	Entry string
+	Err   error
+}
+
+// Error returns the error message.
+func (e *AppLogLevelError) Error() string {
+	return fmt.Sprintf("%v: invalid entry %q: %v", AppLogEnvVar, e.Entry, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *AppLogLevelError) Unwrap() error {
+	return e.Err
+}
+
+// parseAppLogLevels parses the value of the AppLogEnvVar environment
+// variable.  It returns the default level if one is given and the
+// levels for each component.
+func parseAppLogLevels(spec string) (*slog.Level, map[string]slog.Level, error) {
+	var defaultLevel *slog.Level
+	levels := map[string]slog.Level{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		component, levelText, found := strings.Cut(entry, "=")
+		if !found {
+			component, levelText = "", entry
+		}
+		component = strings.TrimSpace(component)
+		var level slog.Level
+		err := level.UnmarshalText([]byte(strings.TrimSpace(levelText)))
+		if err != nil {
+			return nil, nil, &AppLogLevelError{Entry: entry, Err: err}
+		}
+		if !found {
+			defaultLevel = &level
+		} else {
+			levels[component] = level
+		}
+	}
+	return defaultLevel, levels, nil
+}
+
+// initLevels sets the minimum levels from opts and the AppLogEnvVar
+// environment variable.
+func (l *AppLogger) initLevels() error {
+	l.level.Set(l.opts.Level)
+	l.levels = map[string]slog.Level{}
+	spec, ok := os.LookupEnv(AppLogEnvVar)
+	if !ok {
+		return nil
+	}
+	defaultLevel, levels, err := parseAppLogLevels(spec)
+	if err != nil {
+		return err
+	}
+	if defaultLevel != nil {
+		l.level.Set(*defaultLevel)
+	}
+	l.levels = levels
+	return nil
+}
+
+// Level returns the minimum level of entries that are logged for
+// components that do not have their own minimum level.
+func (l *AppLogger) Level() slog.Level {
+	return l.level.Level()
+}
+
+// SetLevel sets the minimum level of entries that are logged for
+// components that do not have their own minimum level.  It can be
+// called at any time.
+func (l *AppLogger) SetLevel(level slog.Level) {
+	l.level.Set(level)
+}
+
+// SetComponentLevel sets the minimum level of entries that are logged
+// for the named component.  It can be called at any time.
+func (l *AppLogger) SetComponentLevel(component string, level slog.Level) {
+	l.levelMu.Lock()
+	defer l.levelMu.Unlock()
+	l.levels[component] = level
+}
+
+// Enabled returns true if entries at the given level are logged for
+// the named component.  Use the empty string for entries that do not
+// belong to a component.
+func (l *AppLogger) Enabled(component string, level slog.Level) bool {
+	l.levelMu.RLock()
+	minLevel, ok := l.levels[component]
+	l.levelMu.RUnlock()
+	if !ok {
+		minLevel = l.level.Level()
+	}
+	return level >= minLevel
+}
+
+// Logf writes an entry at the given level for the named component if
+// the level is enabled.  In the AppLogText format, the entry looks
+// like "timestamp: [LEVEL] component: message" where the component is
+// omitted if it is empty.
+func (l *AppLogger) Logf(component string, level slog.Level, format string, a ...any) error {
+	return l.logf(1, component, level, format, a)
+}
+
+// logf implements Logf() for the caller skip frames above the caller
+// of logf.
+func (l *AppLogger) logf(skip int, component string, level slog.Level, format string, a []any) error {
+	if !l.Enabled(component, level) {
+		return nil
+	}
+	return l.log(skip+1, true, level, component, fmt.Sprintf(format, a...), nil)
+}
+
+// Log writes an entry with structured fields at the given level if the
+// level is enabled.  The args are key-value pairs or slog.Attr values
+// just like the args of slog.Logger.Log().  In the AppLogText format,
+// the fields are appended to the message as key=value pairs.
+func (l *AppLogger) Log(level slog.Level, msg string, args ...any) error {
+	return l.logAttrs(1, "", level, msg, args)
+}
+
+// logAttrs implements Log() for the caller skip frames above the
+// caller of logAttrs.
+func (l *AppLogger) logAttrs(skip int, component string, level slog.Level, msg string, args []any) error {
+	if !l.Enabled(component, level) {
+		return nil
+	}
+
+	// Let slog convert the arguments to attributes.
+	r := slog.NewRecord(time.Time{}, level, msg, 0)
+	r.Add(args...)
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	return l.log(skip+1, true, level, component, msg, attrs)
+}
+
+// Debugf writes an entry at slog.LevelDebug.  See [AppLogger.Logf()].
+func (l *AppLogger) Debugf(format string, a ...any) error {
+	return l.logf(1, "", slog.LevelDebug, format, a)
+}
+
+// Infof writes an entry at slog.LevelInfo.  See [AppLogger.Logf()].
+func (l *AppLogger) Infof(format string, a ...any) error {
+	return l.logf(1, "", slog.LevelInfo, format, a)
+}
+
+// Warnf writes an entry at slog.LevelWarn.  See [AppLogger.Logf()].
+func (l *AppLogger) Warnf(format string, a ...any) error {
+	return l.logf(1, "", slog.LevelWarn, format, a)
+}
+
+// Errorf writes an entry at slog.LevelError.  See [AppLogger.Logf()].
+func (l *AppLogger) Errorf(format string, a ...any) error {
+	return l.logf(1, "", slog.LevelError, format, a)
+}
+
+// AppLogComponent writes leveled entries for one component to an
+// [AppLogger].  See [AppLogger.Component()].
+type AppLogComponent struct {
+	l    *AppLogger
+	name string
+}
+
+// Component returns an AppLogComponent that writes entries for the
+// named component to l.  The entries are filtered by the minimum level
+// for the component if it has one and by the minimum level of l
+// otherwise.
+func (l *AppLogger) Component(name string) *AppLogComponent {
+	return &AppLogComponent{l: l, name: name}
+}
+
+// Name returns the name of the component.
+func (c *AppLogComponent) Name() string {
+	return c.name
+}
+
+// Enabled returns true if entries at the given level are logged for
+// the component.
+func (c *AppLogComponent) Enabled(level slog.Level) bool {
+	return c.l.Enabled(c.name, level)
+}
+
+// Log writes an entry with structured fields.  See
+// [AppLogger.Log()].
+func (c *AppLogComponent) Log(level slog.Level, msg string, args ...any) error {
+	return c.l.logAttrs(1, c.name, level, msg, args)
+}
+
+// Debugf writes an entry at slog.LevelDebug.  See [AppLogger.Logf()].
+func (c *AppLogComponent) Debugf(format string, a ...any) error {
+	return c.l.logf(1, c.name, slog.LevelDebug, format, a)
+}
+
+// Infof writes an entry at slog.LevelInfo.  See [AppLogger.Logf()].
+func (c *AppLogComponent) Infof(format string, a ...any) error {
+	return c.l.logf(1, c.name, slog.LevelInfo, format, a)
+}
+
+// Warnf writes an entry at slog.LevelWarn.  See [AppLogger.Logf()].
+func (c *AppLogComponent) Warnf(format string, a ...any) error {
+	return c.l.logf(1, c.name, slog.LevelWarn, format, a)
+}
+
+// Errorf writes an entry at slog.LevelError.  See [AppLogger.Logf()].
+func (c *AppLogComponent) Errorf(format string, a ...any) error {
+	return c.l.logf(1, c.name, slog.LevelError, format, a)
+}
@@ -0,0 +1,149 @@
+package jrutil
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseAppLogLevels(t *testing.T) {
+	value := " warn, db=debug ,http=ERROR,,x=info+2"
+	defaultLevel, levels, err := parseAppLogLevels(value)
+	if err != nil {
+		t.Fatalf("parseAppLogLevels(%q): %v", value, err)
+	}
+	if defaultLevel == nil || *defaultLevel != slog.LevelWarn {
+		t.Errorf("parseAppLogLevels(%q): expected_default=%v  actual_default=%v",
+			value, slog.LevelWarn, defaultLevel)
+	}
+	expected := map[string]slog.Level{
+		"db":   slog.LevelDebug,
+		"http": slog.LevelError,
+		"x":    slog.LevelInfo + 2,
+	}
+	if !maps.Equal(levels, expected) {
+		t.Errorf("parseAppLogLevels(%q): expected=%v  actual=%v",
+			value, expected, levels)
+	}
+
+	value = "db=loud"
+	_, _, err = parseAppLogLevels(value)
+	var levelErr *AppLogLevelError
+	if !errors.As(err, &levelErr) || levelErr.Entry != value {
+		t.Errorf("parseAppLogLevels(%q): expected=%v  actual=%v",
+			value, &AppLogLevelError{Entry: value}, err)
+	}
+}
+
+func TestAppLoggerLevels(t *testing.T) {
+	t.Setenv(AppLogEnvVar, "db=debug,http=warn")
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewAppLogger(path, nil)
+	if err != nil {
+		t.Fatalf("NewAppLogger: %v", err)
+	}
+	db := l.Component("db")
+	http := l.Component("http")
+	other := l.Component("other")
+	for _, fn := range []func(string, ...any) error{
+		l.Debugf, l.Infof, l.Warnf,
+		db.Debugf, db.Infof,
+		http.Infof, http.Warnf, http.Errorf,
+		other.Debugf, other.Infof,
+	} {
+		err = fn("m")
+		if err != nil {
+			t.Fatalf("Logf: %v", err)
+		}
+	}
+
+	// Change the levels at run time.
+	l.SetLevel(slog.LevelError)
+	l.SetComponentLevel("http", slog.LevelDebug)
+	for _, fn := range []func(string, ...any) error{
+		l.Warnf, l.Errorf, other.Infof, http.Debugf,
+	} {
+		err = fn("n")
+		if err != nil {
+			t.Fatalf("Logf: %v", err)
+		}
+	}
+	if l.Level() != slog.LevelError {
+		t.Errorf("Level: expected=%v  actual=%v", slog.LevelError, l.Level())
+	}
+	enabled := []struct {
+		c        *AppLogComponent
+		level    slog.Level
+		expected bool
+	}{
+		{db, slog.LevelDebug, true},
+		{http, slog.LevelDebug, true},
+		{other, slog.LevelWarn, false},
+		{other, slog.LevelError, true},
+	}
+	for _, d := range enabled {
+		if actual := d.c.Enabled(d.level); actual != d.expected {
+			t.Errorf("Enabled(%v): expected=%v  actual=%v",
+				d.level, d.expected, actual)
+		}
+	}
+
+	// Unleveled entries are always written.
+	err = l.Printf("always")
+	if err != nil {
+		t.Fatalf("Printf: %v", err)
+	}
+
+	err = l.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	expected := []string{
+		"[INFO] m",
+		"[WARN] m",
+		"[DEBUG] db: m",
+		"[INFO] db: m",
+		"[WARN] http: m",
+		"[ERROR] http: m",
+		"[INFO] other: m",
+		"[ERROR] n",
+		"[DEBUG] http: n",
+		"always",
+	}
+	actual := readAppLogLines(t, path)
+	if !slices.Equal(actual, expected) {
+		t.Errorf("Logf: expected=%q  actual=%q", expected, actual)
+	}
+}
+
+func TestAppLoggerLevelEnvDefault(t *testing.T) {
+	t.Setenv(AppLogEnvVar, "debug")
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewAppLogger(path, &AppLoggerOptions{Level: slog.LevelError})
+	if err != nil {
+		t.Fatalf("NewAppLogger: %v", err)
+	}
+	defer l.Close()
+	if l.Level() != slog.LevelDebug {
+		t.Errorf("Level: expected=%v  actual=%v", slog.LevelDebug, l.Level())
+	}
+
+	// The handler follows the level of the logger.
+	h := l.Handler(nil)
+	if !h.Enabled(context.Background(), slog.LevelDebug) {
+		t.Errorf("Handler.Enabled(%v): expected=%v  actual=%v",
+			slog.LevelDebug, true, false)
+	}
+
+	t.Setenv(AppLogEnvVar, "db=nope")
+	_, err = NewAppLogger(path, nil)
+	var levelErr *AppLogLevelError
+	if !errors.As(err, &levelErr) {
+		t.Errorf("NewAppLogger(%v=%q): expected=%v  actual=%v",
+			AppLogEnvVar, "db=nope", &AppLogLevelError{Entry: "db=nope"}, err)
+	}
+}
@@ -0,0 +1,454 @@
+package jrutil
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// appLogTimestampSuffixFormat is the layout of the suffix appended to
+// rotated files when AppLoggerOptions.TimestampSuffix is true.
+const appLogTimestampSuffixFormat = "2006-01-02T15-04-05.000000000"
+
+// AppLogRotation is how often an [AppLogger] rotates its file based
+// on the time of day.
+type AppLogRotation int
+
+const (
+	// RotateNever means the file is never rotated based on the time
+	// of day.
+	RotateNever AppLogRotation = iota
+
+	// RotateHourly means the file is rotated at the start of every
+	// hour.
+	RotateHourly
+
+	// RotateDaily means the file is rotated at midnight local time.
+	RotateDaily
+)
+
+// next returns the time of the first rotation after t or the zero
+// time for RotateNever.
+func (r AppLogRotation) next(t time.Time) time.Time {
+	switch r {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Time{}
+	}
+}
+
+// appLogRotateRetry is how long an AppLogger waits before trying to
+// rotate its file by size again after rotating it failed.
+const appLogRotateRetry = time.Minute
+
+// needsRotation returns true if the file must be rotated before
+// writing an entry of length n.  The caller must hold the lock.
+func (l *AppLogger) needsRotation(n int) bool {
+	if !l.retry.IsZero() && time.Now().Before(l.retry) {
+		return false
+	}
+	if l.opts.MaxSize > 0 && l.size > 0 && l.size+int64(n) > l.opts.MaxSize {
+		return true
+	}
+	return !l.next.IsZero() && !time.Now().Before(l.next)
+}
+
+// Rotate rotates the file immediately regardless of the rotation
+// options.
+func (l *AppLogger) Rotate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return os.ErrClosed
+	}
+	rotateErr, err := l.rotate()
+	if err != nil {
+		return err
+	}
+	return rotateErr
+}
+
+// rotate renames the file, opens a new file, and removes old rotated
+// files.  If the file cannot be reopened, err is returned, and entries
+// cannot be written.  Errors renaming or removing rotated files are
+// returned as rotateErr instead because the file is reopened anyway,
+// so entries can still be written.  The caller must hold the lock.
+func (l *AppLogger) rotate() (rotateErr error, err error) {
+	now := time.Now()
+	l.next = l.opts.RotateEvery.next(now)
+
+	// Close the current file.
+	if l.w != nil {
+		err = l.w.Flush()
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = l.f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// Rename the current file.  If that fails, keep logging to the
+	// same file rather than losing entries.
+	if l.opts.TimestampSuffix {
+		rotateErr = l.rotateTimestamped(now)
+	} else {
+		rotateErr = l.rotateNumbered()
+	}
+	err = l.open()
+	if err != nil {
+		return nil, err
+	}
+	if rotateErr == nil {
+		l.retry = time.Time{}
+	}
+	return rotateErr, nil
+}
+
+// appLogRotatingSuffix is appended, along with a sequence number, to
+// the name of the file while a rotated file is being compressed.
+const appLogRotatingSuffix = ".rotating"
+
+// appLogPending is a rotated file that is being compressed.  While it
+// is, the file has a private name so rotating again never touches it,
+// and name is where it belongs among the rotated files.  The fields
+// are protected by the lock of the AppLogger.
+type appLogPending struct {
+	name    string // name of the rotated file without ".gz"
+	removed bool   // true if the file was removed while compressing
+}
+
+// pendingBackup returns the rotated file named name that is being
+// compressed or nil if there is none.  The caller must hold the lock.
+func (l *AppLogger) pendingBackup(name string) *appLogPending {
+	for _, p := range l.pending {
+		if !p.removed && p.name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// backupExists returns true if the rotated file name exists or is
+// being compressed.  The caller must hold the lock.
+func (l *AppLogger) backupExists(name string) bool {
+	return l.pendingBackup(name) != nil || appLogBackupExists(name)
+}
+
+// renameBackup renames the rotated file from to to.  The caller must
+// hold the lock.
+func (l *AppLogger) renameBackup(from, to string) error {
+	if p := l.pendingBackup(from); p != nil {
+		p.name = to
+		return nil
+	}
+	return renameAppLogBackup(from, to)
+}
+
+// removeBackup removes the rotated file name.  If it is being
+// compressed, it is removed when compression finishes instead.  The
+// caller must hold the lock.
+func (l *AppLogger) removeBackup(name string) error {
+	if p := l.pendingBackup(name); p != nil {
+		p.removed = true
+		return nil
+	}
+	return removeAppLogBackup(name)
+}
+
+// renameRotated renames the file to the rotated file name.  If the
+// rotated files are compressed, the file is given a private name
+// instead and compressed in the background.  The caller must hold the
+// lock.
+func (l *AppLogger) renameRotated(rotated string) error {
+	if !l.opts.Compress {
+		return os.Rename(l.path, rotated)
+	}
+	l.pendingSeq++
+	src := fmt.Sprintf("%v.%v%v", l.path, l.pendingSeq, appLogRotatingSuffix)
+	err := os.Rename(l.path, src)
+	if err != nil {
+		return err
+	}
+	p := &appLogPending{name: rotated}
+	l.pending = append(l.pending, p)
+	l.compressing.Add(1)
+	go l.compressBackup(src, p)
+	return nil
+}
+
+// compressBackup compresses the rotated file src and then moves it to
+// where it belongs among the rotated files.  Only the final renames
+// are done while holding the lock so logging is not blocked.
+func (l *AppLogger) compressBackup(src string, p *appLogPending) {
+	defer l.compressing.Done()
+	tmp := src + appLogCompressingSuffix
+	err := compressAppLog(src, tmp)
+
+	l.mu.Lock()
+	l.pending = slices.DeleteFunc(l.pending, func(q *appLogPending) bool {
+		return q == p
+	})
+	switch {
+	case p.removed:
+		err = removeAppLogBackup(src)
+		if removeErr := os.Remove(tmp); err == nil && !os.IsNotExist(removeErr) {
+			err = removeErr
+		}
+	case err != nil:
+		// Keep the uncompressed file rather than losing it.
+		if renameErr := os.Rename(src, p.name); renameErr != nil {
+			err = renameErr
+		}
+	default:
+		err = os.Rename(tmp, p.name+".gz")
+		if err == nil {
+			err = os.Remove(src)
+		}
+	}
+	l.mu.Unlock()
+
+	if err != nil {
+		l.setBgErr(err)
+	}
+}
+
+// appLogBackupExists returns true if the rotated file name exists with
+// or without the ".gz" suffix.
+func appLogBackupExists(name string) bool {
+	for _, candidate := range []string{name, name + ".gz"} {
+		if _, err := os.Lstat(candidate); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// renameAppLogBackup renames the rotated file from to to keeping the
+// ".gz" suffix if it has one.
+func renameAppLogBackup(from, to string) error {
+	if _, err := os.Lstat(from + ".gz"); err == nil {
+		return os.Rename(from+".gz", to+".gz")
+	}
+	return os.Rename(from, to)
+}
+
+// removeAppLogBackup removes the rotated file name with or without the
+// ".gz" suffix.
+func removeAppLogBackup(name string) error {
+	for _, candidate := range []string{name, name + ".gz"} {
+		if err := os.Remove(candidate); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// rotateNumbered renames the file to path.1 after shifting existing
+// rotated files up by one.
+func (l *AppLogger) rotateNumbered() error {
+	numbered := func(i int) string {
+		return fmt.Sprintf("%v.%v", l.path, i)
+	}
+
+	// Find the first unused number.
+	last := 1
+	for l.backupExists(numbered(last)) {
+		last++
+	}
+
+	// Shift the rotated files up by one.
+	for i := last - 1; i >= 1; i-- {
+		err := l.renameBackup(numbered(i), numbered(i+1))
+		if err != nil {
+			return err
+		}
+	}
+	err := l.renameRotated(numbered(1))
+	if err != nil {
+		return err
+	}
+
+	// Remove rotated files beyond the limit.
+	if l.opts.MaxBackups > 0 {
+		for i := l.opts.MaxBackups + 1; i <= last; i++ {
+			err = l.removeBackup(numbered(i))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// rotateTimestamped renames the file by appending the time of the
+// rotation.
+func (l *AppLogger) rotateTimestamped(now time.Time) error {
+
+	// Pick an unused name.
+	rotated := l.path + "." + now.Format(appLogTimestampSuffixFormat)
+	for i := 1; l.backupExists(rotated); i++ {
+		rotated = fmt.Sprintf("%v.%v.%v", l.path, now.Format(appLogTimestampSuffixFormat), i)
+	}
+	err := l.renameRotated(rotated)
+	if err != nil {
+		return err
+	}
+
+	// Remove the oldest rotated files beyond the limit.  Because of
+	// the timestamp format, sorting the names sorts them by time.
+	if l.opts.MaxBackups > 0 {
+		backups, err := l.timestampedBackups()
+		if err != nil {
+			return err
+		}
+		for len(backups) > l.opts.MaxBackups {
+			err = l.removeBackup(backups[0])
+			if err != nil {
+				return err
+			}
+			backups = backups[1:]
+		}
+	}
+
+	return nil
+}
+
+// timestampedBackups returns the names, without any ".gz" suffix, of
+// the rotated files with timestamp suffixes sorted from oldest to
+// newest including the files that are being compressed.  The caller
+// must hold the lock.
+func (l *AppLogger) timestampedBackups() ([]string, error) {
+	matches, err := filepath.Glob(escapeGlob(l.path) + ".*")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range l.pending {
+		if !p.removed {
+			matches = append(matches, p.name)
+		}
+	}
+	var backups []string
+	for _, match := range matches {
+		if strings.HasSuffix(match, appLogCompressingSuffix) ||
+			strings.HasSuffix(match, appLogRotatingSuffix) {
+			continue
+		}
+		name := strings.TrimSuffix(match, ".gz")
+		suffix := strings.TrimPrefix(name, l.path+".")
+		if len(suffix) < len(appLogTimestampSuffixFormat) {
+			continue
+		}
+		_, err = time.Parse(appLogTimestampSuffixFormat, suffix[:len(appLogTimestampSuffixFormat)])
+		if err != nil {
+			continue
+		}
+		if !slices.Contains(backups, name) {
+			backups = append(backups, name)
+		}
+	}
+	slices.Sort(backups)
+	return backups, nil
+}
+
+// escapeGlob escapes the characters in s that are special to
+// filepath.Match.
+func escapeGlob(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[\`, r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// appLogCompressingSuffix is appended to the name of a file while it
+// is being compressed.
+const appLogCompressingSuffix = ".gz.tmp"
+
+// compressAppLog compresses the file name to tmp.  If it fails, tmp is
+// removed so a partially compressed file is never left behind.
+func compressAppLog(name string, tmp string) (err error) {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(tmp)
+		}
+	}()
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if err != nil {
+		return err
+	}
+	err = zw.Close()
+	if err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// Reopen closes and reopens the file which is needed after an external
+// tool like logrotate has moved the file.
+func (l *AppLogger) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return os.ErrClosed
+	}
+	if l.w != nil {
+		err := l.w.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	err := l.f.Close()
+	if err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// watchSIGHUP starts a goroutine that reopens the file each time the
+// process receives SIGHUP until Close() is called.
+func (l *AppLogger) watchSIGHUP() {
+	l.hup = make(chan os.Signal, 1)
+	signal.Notify(l.hup, syscall.SIGHUP)
+	l.done.Add(1)
+	go func() {
+		defer l.done.Done()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-l.hup:
+				// There is nowhere to report the error, but the next
+				// write will fail if the file could not be reopened.
+				_ = l.Reopen()
+			}
+		}
+	}()
+}
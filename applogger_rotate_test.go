@@ -0,0 +1,368 @@
+package jrutil
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// readAppLogBackup returns the timestamp-free lines of a rotated file
+// decompressing it if its name ends with ".gz".
+func readAppLogBackup(t *testing.T, path string) []string {
+	if filepath.Ext(path) != ".gz" {
+		return readAppLogLines(t, path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader(%q): %v", path, err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("ReadAll(%q): %v", path, err)
+	}
+	plain := filepath.Join(t.TempDir(), "plain.log")
+	err = os.WriteFile(plain, data, 0644)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return readAppLogLines(t, plain)
+}
+
+// listAppLogFiles returns the sorted base names of the files in dir.
+func listAppLogFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestAppLogRotationNext(t *testing.T) {
+	data := []struct {
+		rotation AppLogRotation
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			rotation: RotateNever,
+			now:      time.Date(2024, 12, 31, 23, 30, 15, 0, time.UTC),
+			expected: time.Time{},
+		},
+		{
+			rotation: RotateHourly,
+			now:      time.Date(2024, 12, 31, 23, 30, 15, 0, time.UTC),
+			expected: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			rotation: RotateDaily,
+			now:      time.Date(2024, 12, 31, 23, 30, 15, 0, time.UTC),
+			expected: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			rotation: RotateHourly,
+			now:      time.Date(2024, 6, 1, 9, 59, 0, 0, time.UTC),
+			expected: time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, d := range data {
+		actual := d.rotation.next(d.now)
+		if !actual.Equal(d.expected) {
+			t.Errorf("next(%v, %v): expected=%v  actual=%v",
+				d.rotation, d.now, d.expected, actual)
+		}
+	}
+}
+
+func TestAppLoggerMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	// Each entry is 25 bytes for the timestamp and separator plus 2
+	// bytes for the message and EOL, so two entries fit in a file.
+	l, err := NewAppLogger(path, &AppLoggerOptions{MaxSize: 60, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("NewAppLogger: %v", err)
+	}
+	for _, msg := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		err = l.Printf("%v", msg)
+		if err != nil {
+			t.Fatalf("Printf: %v", err)
+		}
+	}
+	err = l.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	data := []struct {
+		name     string
+		expected []string
+	}{
+		{"app.log", []string{"g"}},
+		{"app.log.1", []string{"e", "f"}},
+		{"app.log.2", []string{"c", "d"}},
+	}
+	for _, d := range data {
+		actual := readAppLogLines(t, filepath.Join(filepath.Dir(path), d.name))
+		if !slices.Equal(actual, d.expected) {
+			t.Errorf("AppLogger(%v): expected=%q  actual=%q",
+				d.name, d.expected, actual)
+		}
+	}
+	expected := []string{"app.log", "app.log.1", "app.log.2"}
+	actual := listAppLogFiles(t, filepath.Dir(path))
+	if !slices.Equal(actual, expected) {
+		t.Errorf("AppLogger(MaxBackups=2): expected=%q  actual=%q",
+			expected, actual)
+	}
+}
+
+// TestAppLoggerRotateError tests that entries are still written when
+// the file cannot be rotated.
+func TestAppLoggerRotateError(t *testing.T) {
+
+	// Adding ".1" to the 254 byte base name of the file makes the name
+	// too long, so every rename fails.
+	dir := t.TempDir()
+	base := strings.Repeat("x", 250) + ".log"
+	path := filepath.Join(dir, base)
+	l, err := NewAppLogger(path, &AppLoggerOptions{MaxSize: 60})
+	if err != nil {
+		t.Fatalf("NewAppLogger: %v", err)
+	}
+	msgs := []string{"a", "b", "c", "d", "e"}
+	for _, msg := range msgs {
+		err = l.Printf("%v", msg)
+		if err != nil {
+			t.Fatalf("Printf(%q): %v", msg, err)
+		}
+	}
+	if l.retry.IsZero() {
+		t.Errorf("AppLogger(MaxSize=60): expected_retry>%v  actual_retry=%v",
+			time.Now(), l.retry)
+	}
+	err = l.Close()
+	if err == nil {
+		t.Errorf("Close: expected=%v  actual=%v", "rename error", err)
+	}
+
+	actual := readAppLogLines(t, path)
+	if !slices.Equal(actual, msgs) {
+		t.Errorf("AppLogger(MaxSize=60): expected=%q  actual=%q", msgs, actual)
+	}
+	files := listAppLogFiles(t, dir)
+	if !slices.Equal(files, []string{base}) {
+		t.Errorf("AppLogger(MaxSize=60): expected_files=%q  actual_files=%q",
+			[]string{base}, files)
+	}
+}
+
+func TestAppLoggerTimestampSuffix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewAppLogger(path, &AppLoggerOptions{
+		TimestampSuffix: true,
+		MaxBackups:      2,
+		BufferSize:      1024,
+	})
+	if err != nil {
+		t.Fatalf("NewAppLogger: %v", err)
+	}
+	for _, msg := range []string{"a", "b", "c", "d"} {
+		err = l.Printf("%v", msg)
+		if err != nil {
+			t.Fatalf("Printf: %v", err)
+		}
+		err = l.Rotate()
+		if err != nil {
+			t.Fatalf("Rotate: %v", err)
+		}
+	}
+	err = l.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	backups, err := l.timestampedBackups()
+	if err != nil {
+		t.Fatalf("timestampedBackups: %v", err)
+	}
+	var actual []string
+	for _, backup := range backups {
+		actual = append(actual, readAppLogLines(t, backup)...)
+	}
+	expected := []string{"c", "d"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("AppLogger(MaxBackups=2): expected=%q  actual=%q",
+			expected, actual)
+	}
+}
+
+func TestAppLoggerCompress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewAppLogger(path, &AppLoggerOptions{Compress: true})
+	if err != nil {
+		t.Fatalf("NewAppLogger: %v", err)
+	}
+	for _, msg := range []string{"a", "b", "c"} {
+		err = l.Printf("%v", msg)
+		if err != nil {
+			t.Fatalf("Printf: %v", err)
+		}
+		err = l.Rotate()
+		if err != nil {
+			t.Fatalf("Rotate: %v", err)
+		}
+	}
+	err = l.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	expected := []string{"app.log", "app.log.1.gz", "app.log.2.gz", "app.log.3.gz"}
+	actual := listAppLogFiles(t, filepath.Dir(path))
+	if !slices.Equal(actual, expected) {
+		t.Errorf("AppLogger(Compress=true): expected=%q  actual=%q",
+			expected, actual)
+	}
+	for i, msg := range []string{"c", "b", "a"} {
+		name := fmt.Sprintf("%v.%v.gz", path, i+1)
+		actual := readAppLogBackup(t, name)
+		expected := []string{msg}
+		if !slices.Equal(actual, expected) {
+			t.Errorf("AppLogger(%v): expected=%q  actual=%q",
+				filepath.Base(name), expected, actual)
+		}
+	}
+}
+
+// TestAppLoggerCompressMaxBackups tests rotating faster than the
+// rotated files can be compressed which must neither count the files
+// being compressed twice nor remove them out from under the
+// compression.
+func TestAppLoggerCompressMaxBackups(t *testing.T) {
+	for _, timestamped := range []bool{false, true} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		l, err := NewAppLogger(path, &AppLoggerOptions{
+			TimestampSuffix: timestamped,
+			MaxBackups:      3,
+			Compress:        true,
+		})
+		if err != nil {
+			t.Fatalf("NewAppLogger: %v", err)
+		}
+		msgs := []string{"a", "b", "c", "d", "e", "f"}
+		for _, msg := range msgs {
+			err = l.Printf("%v", msg)
+			if err != nil {
+				t.Fatalf("Printf: %v", err)
+			}
+			err = l.Rotate()
+			if err != nil {
+				t.Fatalf("Rotate(TimestampSuffix=%v): %v", timestamped, err)
+			}
+		}
+		err = l.Close()
+		if err != nil {
+			t.Fatalf("Close(TimestampSuffix=%v): %v", timestamped, err)
+		}
+
+		// Only the current file and the three newest compressed
+		// files remain.  Reading them in reverse order of their
+		// names gives the newest messages for numbered names, and
+		// reading them in order gives them for timestamped names.
+		names := listAppLogFiles(t, dir)
+		if len(names) != 4 || names[0] != "app.log" {
+			t.Fatalf("AppLogger(TimestampSuffix=%v): expected_length=%v  actual_length=%v  (%q)",
+				timestamped, 4, len(names), names)
+		}
+		backups := names[1:]
+		if !timestamped {
+			slices.Reverse(backups)
+		}
+		var actual []string
+		for _, name := range backups {
+			if filepath.Ext(name) != ".gz" {
+				t.Errorf("AppLogger(TimestampSuffix=%v): %v was not compressed",
+					timestamped, name)
+				continue
+			}
+			actual = append(actual, readAppLogBackup(t, filepath.Join(dir, name))...)
+		}
+		expected := msgs[3:]
+		if !slices.Equal(actual, expected) {
+			t.Errorf("AppLogger(TimestampSuffix=%v): expected=%q  actual=%q",
+				timestamped, expected, actual)
+		}
+	}
+}
+
+func TestAppLoggerReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewAppLogger(path, &AppLoggerOptions{ReopenOnSIGHUP: true})
+	if err != nil {
+		t.Fatalf("NewAppLogger: %v", err)
+	}
+	defer l.Close()
+	err = l.Printf("before")
+	if err != nil {
+		t.Fatalf("Printf: %v", err)
+	}
+
+	// Move the file like logrotate would and then reopen it.
+	err = os.Rename(path, path+".old")
+	if err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	err = l.Reopen()
+	if err != nil {
+		t.Fatalf("Reopen: %v", err)
+	}
+	err = l.Printf("after")
+	if err != nil {
+		t.Fatalf("Printf: %v", err)
+	}
+	actual := readAppLogLines(t, path)
+	expected := []string{"after"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("Reopen: expected=%q  actual=%q", expected, actual)
+	}
+
+	// Do it again using SIGHUP.
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("FindProcess: %v", err)
+	}
+	err = os.Rename(path, path+".old")
+	if err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if err = p.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("cannot send SIGHUP: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err = os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("AppLogger(ReopenOnSIGHUP=true): file was not reopened")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
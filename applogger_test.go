@@ -0,0 +1,163 @@
+package jrutil
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAppLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewAppLogger(path, nil)
+	if err != nil {
+		t.Fatalf("NewAppLogger: %v", err)
+	}
+	err = l.Printf("Hello, %s!", "World")
+	if err != nil {
+		t.Fatalf("Printf: %v", err)
+	}
+
+	// Unbuffered entries are written immediately.
+	actual := readAppLogLines(t, path)
+	expected := []string{"Hello, World!"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("Printf: expected=%q  actual=%q", expected, actual)
+	}
+
+	// The handler shares the file.
+	slog.New(l.Handler(nil)).Info("from slog", "k", "v")
+
+	err = l.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	actual = readAppLogLines(t, path)
+	expected = []string{"Hello, World!", "[INFO] from slog k=v"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("Handler: expected=%q  actual=%q", expected, actual)
+	}
+
+	// Using a closed logger fails.
+	if err = l.Printf("late"); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Printf: expected=%v  actual=%v", os.ErrClosed, err)
+	}
+	if err = l.Close(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Close: expected=%v  actual=%v", os.ErrClosed, err)
+	}
+}
+
+func TestAppLoggerBuffered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewAppLogger(path, &AppLoggerOptions{
+		BufferSize:    4096,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewAppLogger: %v", err)
+	}
+	err = l.Printf("one")
+	if err != nil {
+		t.Fatalf("Printf: %v", err)
+	}
+	if actual := readAppLogLines(t, path); len(actual) != 0 {
+		t.Errorf("Printf: expected=%q  actual=%q", []string{}, actual)
+	}
+	err = l.Flush()
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	actual := readAppLogLines(t, path)
+	expected := []string{"one"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("Flush: expected=%q  actual=%q", expected, actual)
+	}
+	err = l.Printf("two")
+	if err != nil {
+		t.Fatalf("Printf: %v", err)
+	}
+	err = l.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	actual = readAppLogLines(t, path)
+	expected = []string{"one", "two"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("Close: expected=%q  actual=%q", expected, actual)
+	}
+}
+
+func TestAppLoggerFlushInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewAppLogger(path, &AppLoggerOptions{
+		BufferSize:    4096,
+		FlushInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewAppLogger: %v", err)
+	}
+	defer l.Close()
+	err = l.Printf("one")
+	if err != nil {
+		t.Fatalf("Printf: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(readAppLogLines(t, path)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("AppLogger(FlushInterval=%v): buffer was not flushed",
+				10*time.Millisecond)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAppLoggerConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := NewAppLogger(path, &AppLoggerOptions{BufferSize: 100})
+	if err != nil {
+		t.Fatalf("NewAppLogger: %v", err)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				err := l.Printf("g=%v i=%v %v", g, i, strings.Repeat("x", 50))
+				if err != nil {
+					t.Errorf("Printf(g=%v, i=%v): %v", g, i, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	err = l.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	actual := readAppLogLines(t, path)
+	if len(actual) != 1000 {
+		t.Fatalf("Printf: expected_length=%v  actual_length=%v",
+			1000, len(actual))
+	}
+	seen := map[string]bool{}
+	for _, line := range actual {
+		seen[line] = true
+	}
+	for g := 0; g < 10; g++ {
+		for i := 0; i < 100; i++ {
+			line := fmt.Sprintf("g=%v i=%v %v", g, i, strings.Repeat("x", 50))
+			if !seen[line] {
+				t.Fatalf("Printf: missing line %q", line)
+			}
+		}
+	}
+}
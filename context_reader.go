@@ -0,0 +1,114 @@
+package jrutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// readDeadliner is implemented by readers such as *os.File and
+// net.Conn whose blocked reads can be interrupted by setting a
+// deadline.
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// ContextReader is an io.Reader that stops reading when its context
+// is cancelled.  See [NewContextReader()].
+type ContextReader struct {
+	ctx         context.Context
+	r           io.Reader
+	stopWatch   func() bool
+	interrupted chan struct{}
+}
+
+// NewContextReader returns a new ContextReader that reads from r
+// until ctx is cancelled after which every read fails with an error
+// that wraps ctx.Err().  If r has a SetReadDeadline() method, as
+// *os.File pipes and net.Conn do, a read that is blocked when ctx is
+// cancelled is interrupted by setting the read deadline to a time in
+// the past.  Other readers are only checked for cancellation between
+// reads.
+//
+// Call Stop() when done reading to release the resources associated
+// with watching ctx.  To use [ReadLine()] with a context, wrap the
+// ContextReader in a bufio.Reader:
+//
+//	cr := jrutil.NewContextReader(ctx, conn)
+//	defer cr.Stop()
+//	line, err := jrutil.ReadLine(bufio.NewReader(cr))
+func NewContextReader(ctx context.Context, r io.Reader) *ContextReader {
+	cr := &ContextReader{
+		ctx: ctx,
+		r:   r,
+	}
+	if d, ok := r.(readDeadliner); ok {
+		cr.interrupted = make(chan struct{})
+		cr.stopWatch = context.AfterFunc(ctx, func() {
+			// Errors are ignored because some files, like regular
+			// files, do not support deadlines.
+			_ = d.SetReadDeadline(time.Unix(1, 0))
+			close(cr.interrupted)
+		})
+	}
+	return cr
+}
+
+// Read reads from the underlying reader unless the context has been
+// cancelled.
+func (cr *ContextReader) Read(p []byte) (int, error) {
+	if cr.ctx.Err() != nil {
+		return 0, contextError(cr.ctx)
+	}
+	n, err := cr.r.Read(p)
+	if err != nil && cr.ctx.Err() != nil {
+		return n, contextError(cr.ctx)
+	}
+	return n, err
+}
+
+// Stop stops watching the context.  If the context was already
+// cancelled and the read deadline of the underlying reader was used
+// to interrupt a blocked read, the read deadline is cleared so the
+// underlying reader can be used again.  Stop does not close the
+// underlying reader.
+func (cr *ContextReader) Stop() {
+	if cr.stopWatch == nil {
+		return
+	}
+	if !cr.stopWatch() {
+		<-cr.interrupted
+		_ = cr.r.(readDeadliner).SetReadDeadline(time.Time{})
+	}
+	cr.stopWatch = nil
+}
+
+// contextError returns the error used to report that reading stopped
+// because ctx was cancelled.
+func contextError(ctx context.Context) error {
+	return fmt.Errorf("read cancelled: %w", ctx.Err())
+}
+
+// ForEachLineContext is the same as [ForEachLine()] except it stops
+// when ctx is cancelled.  The context is checked between lines, and
+// if r supports read deadlines, a blocked read is interrupted as
+// described for [NewContextReader()].  Either way, the error returned
+// wraps ctx.Err() so it can be tested with errors.Is().
+func ForEachLineContext(
+	ctx context.Context,
+	r io.Reader,
+	stripEOL bool,
+	strict bool,
+	fn func(string) (bool, error),
+) error {
+	cr := NewContextReader(ctx, r)
+	defer cr.Stop()
+	return ForEachLine(cr, stripEOL, strict,
+		func(line string) (bool, error) {
+			if ctx.Err() != nil {
+				return false, contextError(ctx)
+			}
+			return fn(line)
+		})
+}
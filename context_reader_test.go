@@ -0,0 +1,90 @@
+package jrutil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestForEachLineContext tests that the context is checked between
+// lines.
+func TestForEachLineContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var actual []string
+	r := strings.NewReader("foo\nbar\nbaz\n")
+	err := ForEachLineContext(ctx, r, true, true,
+		func(line string) (bool, error) {
+			actual = append(actual, line)
+			if line == "bar" {
+				cancel()
+			}
+			return true, nil
+		})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ForEachLineContext: expected=%v  actual=%v",
+			context.Canceled, err)
+	}
+	expected := []string{"foo", "bar"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("ForEachLineContext: expected=%q  actual=%q",
+			expected, actual)
+	}
+}
+
+// TestForEachLineContextBlocked tests that a read that is blocked on
+// a pipe is interrupted when the context is cancelled.
+func TestForEachLineContextBlocked(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	defer pr.Close()
+	defer pw.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Write one line and then leave the pipe open so the next read
+	// blocks.
+	_, err = pw.WriteString("foo\n")
+	if err != nil {
+		t.Fatalf("WriteString: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- ForEachLineContext(ctx, pr, true, false,
+			func(line string) (bool, error) {
+				cancel()
+				return true, nil
+			})
+	}()
+
+	select {
+	case err = <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("ForEachLineContext: expected=%v  actual=%v",
+				context.Canceled, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("ForEachLineContext: blocked read was not interrupted")
+	}
+
+	// The read deadline should have been cleared so the pipe is
+	// usable again.
+	_, err = pw.WriteString("bar\n")
+	if err != nil {
+		t.Fatalf("WriteString: %v", err)
+	}
+	buf := make([]byte, 4)
+	n, err := pr.Read(buf)
+	if err != nil || string(buf[:n]) != "bar\n" {
+		t.Errorf("Read: expected=%q  actual=%q (%v)", "bar\n", buf[:n], err)
+	}
+}
@@ -0,0 +1,243 @@
+package jrutil
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// Decompressor returns an io.ReadCloser that decompresses r.  Closing
+// the io.ReadCloser must not close r.
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+// decompressorEntry associates a Decompressor with the magic bytes
+// that identify its format.  Because some magic bytes are also
+// ordinary text, probe, if not nil, is called with up to
+// decompressProbeSize bytes from the start of the input to confirm
+// the input really is compressed.  If atEOF is true, head holds all of
+// the input.
+type decompressorEntry struct {
+	magic []byte
+	fn    Decompressor
+	probe func(head []byte, atEOF bool) bool
+}
+
+// decompressProbeSize is the number of bytes passed to the probe
+// function of a decompressorEntry.
+const decompressProbeSize = 4096
+
+// decompressors holds the registered decompressors.
+var (
+	decompressorsMu sync.RWMutex
+	decompressors   []decompressorEntry
+)
+
+func init() {
+	RegisterDecompressor([]byte{0x1f, 0x8b}, func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	})
+
+	// Because "BZh" is ordinary text, the bzip2 magic also includes
+	// the block size digit and the magic that starts the first block
+	// or, for an empty stream, the end of the stream.
+	for level := byte('1'); level <= '9'; level++ {
+		for _, block := range []string{
+			"\x31\x41\x59\x26\x53\x59", // first block
+			"\x17\x72\x45\x38\x50\x90", // end of stream
+		} {
+			magic := append([]byte{'B', 'Z', 'h', level}, block...)
+			RegisterDecompressor(magic, func(r io.Reader) (io.ReadCloser, error) {
+				return io.NopCloser(bzip2.NewReader(r)), nil
+			})
+		}
+	}
+
+	// Because "x^" and "x\x01" are ordinary text, zlib input is
+	// probed before it is trusted.
+	for _, flg := range []byte{0x01, 0x5e, 0x9c, 0xda} {
+		registerDecompressor([]byte{0x78, flg}, func(r io.Reader) (io.ReadCloser, error) {
+			return zlib.NewReader(r)
+		}, probeZlib)
+	}
+}
+
+// probeZlib returns true if head is the start of a zlib stream which
+// requires a valid header checksum and that head decompresses without
+// errors other than being truncated when there is more input.
+func probeZlib(head []byte, atEOF bool) bool {
+	if len(head) < 2 || (uint16(head[0])<<8|uint16(head[1]))%31 != 0 {
+		return false
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(head))
+	if err != nil {
+		return false
+	}
+	defer zr.Close()
+	_, err = io.Copy(io.Discard, zr)
+	return err == nil || (!atEOF && err == io.ErrUnexpectedEOF)
+}
+
+// RegisterDecompressor registers fn as the Decompressor for input that
+// starts with the magic bytes.  Gzip, bzip2, and zlib are registered
+// by default.  Other formats, like zstd whose magic bytes are
+// 0x28 0xb5 0x2f 0xfd, can be added by registering a Decompressor from
+// a third-party package.  If more than one magic matches, the longest
+// one wins.  Registering the same magic again replaces the previous
+// Decompressor.
+func RegisterDecompressor(magic []byte, fn Decompressor) {
+	registerDecompressor(magic, fn, nil)
+}
+
+// registerDecompressor is the same as RegisterDecompressor() except it
+// also registers a probe function.  See decompressorEntry.
+func registerDecompressor(
+	magic []byte,
+	fn Decompressor,
+	probe func(head []byte, atEOF bool) bool,
+) {
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+	for i := range decompressors {
+		if bytes.Equal(decompressors[i].magic, magic) {
+			decompressors[i].fn = fn
+			decompressors[i].probe = probe
+			return
+		}
+	}
+	decompressors = append(decompressors, decompressorEntry{
+		magic: bytes.Clone(magic),
+		fn:    fn,
+		probe: probe,
+	})
+}
+
+// findDecompressor returns the entry for the input that starts with
+// head, or nil if the input does not appear to be compressed.
+func findDecompressor(head []byte) *decompressorEntry {
+	decompressorsMu.RLock()
+	defer decompressorsMu.RUnlock()
+	var result *decompressorEntry
+	for i := range decompressors {
+		d := &decompressors[i]
+		if bytes.HasPrefix(head, d.magic) {
+			if result == nil || len(d.magic) > len(result.magic) {
+				result = d
+			}
+		}
+	}
+	if result == nil {
+		return nil
+	}
+	entry := *result
+	return &entry
+}
+
+// maxMagicLength returns the length of the longest registered magic.
+func maxMagicLength() int {
+	decompressorsMu.RLock()
+	defer decompressorsMu.RUnlock()
+	result := 0
+	for _, d := range decompressors {
+		result = max(result, len(d.magic))
+	}
+	return result
+}
+
+// multiCloser is an io.ReadCloser that closes several io.Closers in
+// order returning the first error.
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close closes each io.Closer in order.
+func (mc *multiCloser) Close() error {
+	var errs []error
+	for _, c := range mc.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Decompress sniffs the magic bytes at the start of r and, if they
+// belong to a registered compression format, returns an io.ReadCloser
+// that decompresses r.  Otherwise, the returned io.ReadCloser reads r
+// unchanged.  Because the zlib magic bytes can also be ordinary text,
+// input that starts with them is only decompressed if the first few
+// KiB decompress without errors.  Closing the returned io.ReadCloser
+// does not close r.
+func Decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(maxMagicLength())
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	d := findDecompressor(head)
+	if d == nil {
+		return io.NopCloser(br), nil
+	}
+
+	// If the magic bytes might be text, make sure the input really
+	// is compressed.  Otherwise, read it unchanged.
+	if d.probe != nil {
+		head, err = br.Peek(decompressProbeSize)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, err
+		}
+		if !d.probe(head, err == io.EOF) {
+			return io.NopCloser(br), nil
+		}
+	}
+
+	return d.fn(br)
+}
+
+// OpenLines opens the file at path for reading lines of text.  If the
+// file is compressed in one of the formats registered with
+// [RegisterDecompressor()], it is transparently decompressed.  Closing
+// the returned io.ReadCloser closes the file.
+func OpenLines(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := Decompress(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &multiCloser{
+		Reader:  rc,
+		closers: []io.Closer{rc, f},
+	}, nil
+}
+
+// ForEachLineInFile is the same as [ForEachLine()] except it reads the
+// lines from the file at path which is opened using [OpenLines()] so
+// compressed files are transparently decompressed.  The file is
+// closed before returning.  Errors from reading take precedence over
+// errors from closing.
+func ForEachLineInFile(
+	path string,
+	stripEOL bool,
+	strict bool,
+	fn func(string) (bool, error),
+) (err error) {
+	rc, err := OpenLines(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := rc.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+	return ForEachLine(rc, stripEOL, strict, fn)
+}
@@ -0,0 +1,194 @@
+package jrutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// bzip2Text is "foo\nbar\r\nbaz" compressed with bzip2.
+const bzip2Text = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\x22\xab\xbe\xb3" +
+	"\x00\x00\x03\xc1\x80\x00\x12\x31\x00\x90\x10\x20\x00\x31\x0c\x08" +
+	"\x12\x83\x26\x8f\x39\x88\x8e\x43\xc5\xdc\x91\x4e\x14\x24\x08\xaa" +
+	"\xef\xac\xc0"
+
+// compressText compresses the text with the writer returned by newW.
+func compressText(
+	t *testing.T,
+	text string,
+	newW func(io.Writer) io.WriteCloser,
+) string {
+	var b bytes.Buffer
+	w := newW(&b)
+	_, err := w.Write([]byte(text))
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return b.String()
+}
+
+func TestForEachLineInFile(t *testing.T) {
+	text := "foo\nbar\r\nbaz"
+	data := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "plain.txt",
+			content: text,
+		},
+		{
+			name:    "short.txt",
+			content: "x",
+		},
+		{
+			name:    "empty.txt",
+			content: "",
+		},
+		{
+			name: "text.gz",
+			content: compressText(t, text, func(w io.Writer) io.WriteCloser {
+				return gzip.NewWriter(w)
+			}),
+		},
+		{
+			name: "text.z",
+			content: compressText(t, text, func(w io.Writer) io.WriteCloser {
+				return zlib.NewWriter(w)
+			}),
+		},
+		{
+			name:    "text.bz2",
+			content: bzip2Text,
+		},
+	}
+
+	dir := t.TempDir()
+	for _, d := range data {
+		path := filepath.Join(dir, d.name)
+		err := os.WriteFile(path, []byte(d.content), 0644)
+		if err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+
+		expected := []string{"foo", "bar", "baz"}
+		switch d.name {
+		case "short.txt":
+			expected = []string{"x"}
+		case "empty.txt":
+			expected = []string{}
+		}
+
+		actual := []string{}
+		err = ForEachLineInFile(path, true, false,
+			func(line string) (bool, error) {
+				actual = append(actual, line)
+				return true, nil
+			})
+		if err != nil {
+			t.Errorf("ForEachLineInFile(%v): %v", d.name, err)
+			continue
+		}
+		if !slices.Equal(actual, expected) {
+			t.Errorf("ForEachLineInFile(%v): expected=%q  actual=%q",
+				d.name, expected, actual)
+		}
+	}
+}
+
+// TestForEachLineInFileMagicText tests that plain text that happens to
+// start with the magic bytes of a compression format is read
+// unchanged.
+func TestForEachLineInFileMagicText(t *testing.T) {
+	data := []string{
+		"x^2 + y^2\nis a circle\n",
+		"x\x01 is odd\n",
+		"x\x9c\n",
+		"x^",
+		"BZh is not bzip2\n",
+		"BZh9 is not bzip2 either\n",
+		"BZh",
+	}
+	dir := t.TempDir()
+	for i, text := range data {
+		path := filepath.Join(dir, fmt.Sprintf("magic%v.txt", i))
+		err := os.WriteFile(path, []byte(text), 0644)
+		if err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		actual := ""
+		err = ForEachLineInFile(path, false, false,
+			func(line string) (bool, error) {
+				actual += line
+				return true, nil
+			})
+		if err != nil {
+			t.Errorf("ForEachLineInFile(%q): %v", text, err)
+			continue
+		}
+		if actual != text {
+			t.Errorf("ForEachLineInFile(%q): expected=%q  actual=%q",
+				text, text, actual)
+		}
+	}
+}
+
+// TestRegisterDecompressor tests registering a custom decompressor.
+func TestRegisterDecompressor(t *testing.T) {
+	magic := []byte("JRTEST")
+	RegisterDecompressor(magic, func(r io.Reader) (io.ReadCloser, error) {
+		_, err := io.CopyN(io.Discard, r, int64(len(magic)))
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(r), nil
+	})
+
+	rc, err := Decompress(strings.NewReader("JRTESTfoo\nbar\n"))
+	if err != nil {
+		t.Fatalf("Decompress: %v", err)
+	}
+	defer rc.Close()
+	actual, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(actual) != "foo\nbar\n" {
+		t.Errorf("Decompress: expected=%q  actual=%q", "foo\nbar\n", actual)
+	}
+}
+
+// TestDecompressLargeZlib tests that zlib input that is longer than
+// what is probed is still decompressed.
+func TestDecompressLargeZlib(t *testing.T) {
+	text, _ := makeSampleText(50000)
+	compressed := compressText(t, text, func(w io.Writer) io.WriteCloser {
+		return zlib.NewWriter(w)
+	})
+	if len(compressed) <= decompressProbeSize {
+		t.Fatalf("compressed text is too short: %v", len(compressed))
+	}
+	rc, err := Decompress(strings.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("Decompress: %v", err)
+	}
+	defer rc.Close()
+	actual, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(actual) != text {
+		t.Errorf("Decompress: expected %v bytes  actual %v bytes", len(text), len(actual))
+	}
+}
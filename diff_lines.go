@@ -0,0 +1,357 @@
+package jrutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// DiffOp identifies the kind of a DiffEdit.
+type DiffOp int
+
+const (
+	// DiffEqual means the line is in both inputs.
+	DiffEqual DiffOp = iota
+
+	// DiffDelete means the line is only in the first input.
+	DiffDelete
+
+	// DiffInsert means the line is only in the second input.
+	DiffInsert
+)
+
+// String returns the name of the operation.
+func (op DiffOp) String() string {
+	switch op {
+	case DiffEqual:
+		return "Equal"
+	case DiffDelete:
+		return "Delete"
+	case DiffInsert:
+		return "Insert"
+	default:
+		return "Unknown"
+	}
+}
+
+// DiffEdit is one step of the edit script returned by [DiffLines()].
+type DiffEdit struct {
+
+	// Op is the kind of edit.
+	Op DiffOp
+
+	// ALine is the 1-based line number in the first input or zero
+	// for DiffInsert.
+	ALine int
+
+	// BLine is the 1-based line number in the second input or zero
+	// for DiffDelete.
+	BLine int
+
+	// Text is the line with its EOL sequence still attached.  For
+	// DiffEqual, the line is from the second input.
+	Text string
+}
+
+// readDiffLines reads all of the lines of r using [ReadLine()].
+func readDiffLines(r io.Reader) ([]string, error) {
+	var lines []string
+	br := bufio.NewReader(r)
+	for {
+		line, err := ReadLine(br)
+		if line != "" {
+			lines = append(lines, line)
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// DiffLines reads the lines of a and b using [ReadLine()] and returns
+// the shortest edit script that turns the lines of a into the lines of
+// b as computed by the Myers diff algorithm.  If ignoreEOL is true,
+// lines that only differ in their EOL sequences are considered equal.
+// Use [WriteUnifiedDiff()] to render the result.
+func DiffLines(a, b io.Reader, ignoreEOL bool) ([]DiffEdit, error) {
+	aLines, err := readDiffLines(a)
+	if err != nil {
+		return nil, err
+	}
+	bLines, err := readDiffLines(b)
+	if err != nil {
+		return nil, err
+	}
+
+	// Map each distinct line to an integer so lines can be compared
+	// quickly.
+	ids := map[string]int{}
+	toIDs := func(lines []string) []int {
+		result := make([]int, len(lines))
+		for i, line := range lines {
+			if ignoreEOL {
+				line = StripEOL(line)
+			}
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			result[i] = id
+		}
+		return result
+	}
+
+	ops := myersDiff(toIDs(aLines), toIDs(bLines))
+
+	// Convert the operations into edits.
+	edits := make([]DiffEdit, 0, len(ops))
+	x, y := 0, 0
+	for _, op := range ops {
+		switch op {
+		case DiffEqual:
+			edits = append(edits, DiffEdit{Op: op, ALine: x + 1, BLine: y + 1, Text: bLines[y]})
+			x++
+			y++
+		case DiffDelete:
+			edits = append(edits, DiffEdit{Op: op, ALine: x + 1, Text: aLines[x]})
+			x++
+		case DiffInsert:
+			edits = append(edits, DiffEdit{Op: op, BLine: y + 1, Text: bLines[y]})
+			y++
+		}
+	}
+
+	return edits, nil
+}
+
+// myersDiff returns the operations of the shortest edit script that
+// turns a into b.
+func myersDiff(a, b []int) []DiffOp {
+
+	// Handle the common prefix and suffix separately which is cheap
+	// and often reduces the work considerably.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a = a[prefix : len(a)-suffix]
+	b = b[prefix : len(b)-suffix]
+
+	// Find the furthest reaching path for each number of edits, d,
+	// and save the state at the start of each round so the path can
+	// be recovered.  v[offset+k] is the furthest x on diagonal k.
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // down, an insertion
+			} else {
+				x = v[offset+k-1] + 1 // right, a deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		if done {
+			break
+		}
+	}
+
+	// Recover the path backwards.
+	var ops []DiffOp
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		vd := trace[d] // vd[d+k] is v[offset+k] at the start of round d
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && vd[d+k-1] < vd[d+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := vd[d+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, DiffEqual)
+			x--
+			y--
+		}
+		if x == prevX {
+			ops = append(ops, DiffInsert)
+		} else {
+			ops = append(ops, DiffDelete)
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, DiffEqual)
+		x--
+		y--
+	}
+
+	// Put everything back together.
+	result := make([]DiffOp, 0, prefix+len(ops)+suffix)
+	for i := 0; i < prefix; i++ {
+		result = append(result, DiffEqual)
+	}
+	for i := len(ops) - 1; i >= 0; i-- {
+		result = append(result, ops[i])
+	}
+	for i := 0; i < suffix; i++ {
+		result = append(result, DiffEqual)
+	}
+
+	return result
+}
+
+// WriteUnifiedDiff writes the edit script returned by [DiffLines()] to
+// w in unified diff format with the given number of lines of context
+// around each change.  The names of the two inputs are written in the
+// header.  Nothing is written if there are no changes.  For best
+// performance, w should be a bufio.Writer.
+func WriteUnifiedDiff(
+	w io.Writer,
+	edits []DiffEdit,
+	nameA string,
+	nameB string,
+	context int,
+) error {
+	context = max(context, 0)
+
+	// Find the changes.
+	var changes []int
+	for i, edit := range edits {
+		if edit.Op != DiffEqual {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// Write the header.
+	_, err := fmt.Fprintf(w, "--- %v\n+++ %v\n", nameA, nameB)
+	if err != nil {
+		return err
+	}
+
+	// Group the changes into hunks where changes that are close
+	// enough together to share context are in the same hunk.
+	for i := 0; i < len(changes); {
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*context+1 {
+			j++
+		}
+		start := max(changes[i]-context, 0)
+		end := min(changes[j]+context+1, len(edits))
+		err = writeUnifiedHunk(w, edits, start, end)
+		if err != nil {
+			return err
+		}
+		i = j + 1
+	}
+
+	return nil
+}
+
+// writeUnifiedHunk writes the edits from start to end as a hunk.
+func writeUnifiedHunk(w io.Writer, edits []DiffEdit, start, end int) error {
+
+	// Find the first line of each input in the hunk and count the
+	// lines.
+	aStart, bStart := 0, 0
+	aCount, bCount := 0, 0
+	for _, edit := range edits[start:end] {
+		if edit.Op != DiffInsert {
+			if aCount == 0 {
+				aStart = edit.ALine
+			}
+			aCount++
+		}
+		if edit.Op != DiffDelete {
+			if bCount == 0 {
+				bStart = edit.BLine
+			}
+			bCount++
+		}
+	}
+
+	// An empty range starts at the line before the hunk.
+	if aCount == 0 {
+		aStart = unifiedLineBefore(edits, start, func(e DiffEdit) int { return e.ALine })
+	}
+	if bCount == 0 {
+		bStart = unifiedLineBefore(edits, start, func(e DiffEdit) int { return e.BLine })
+	}
+
+	// Write the hunk.
+	_, err := fmt.Fprintf(w, "@@ -%v +%v @@\n",
+		unifiedRange(aStart, aCount), unifiedRange(bStart, bCount))
+	if err != nil {
+		return err
+	}
+	for _, edit := range edits[start:end] {
+		prefix := " "
+		switch edit.Op {
+		case DiffDelete:
+			prefix = "-"
+		case DiffInsert:
+			prefix = "+"
+		}
+		line := prefix + edit.Text
+		switch EOLOf(edit.Text) {
+		case EOLNone:
+			line += "\n\\ No newline at end of file\n"
+		case EOLMac:
+			line += "\n"
+		}
+		_, err = io.WriteString(w, line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unifiedLineBefore returns the line number of the last line before
+// edits[start] that has a line number according to lineOf.
+func unifiedLineBefore(edits []DiffEdit, start int, lineOf func(DiffEdit) int) int {
+	for i := start - 1; i >= 0; i-- {
+		if n := lineOf(edits[i]); n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// unifiedRange formats a range of lines for a hunk header.
+func unifiedRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%v", start)
+	}
+	return fmt.Sprintf("%v,%v", start, count)
+}
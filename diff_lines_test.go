@@ -0,0 +1,189 @@
+package jrutil
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// applyDiffEdits returns the lines of the first and second inputs
+// recovered from edits.
+func applyDiffEdits(edits []DiffEdit) (string, string) {
+	var a, b strings.Builder
+	for _, edit := range edits {
+		if edit.Op != DiffInsert {
+			a.WriteString(edit.Text)
+		}
+		if edit.Op != DiffDelete {
+			b.WriteString(edit.Text)
+		}
+	}
+	return a.String(), b.String()
+}
+
+// countDiffChanges returns the number of edits that are not DiffEqual.
+func countDiffChanges(edits []DiffEdit) int {
+	count := 0
+	for _, edit := range edits {
+		if edit.Op != DiffEqual {
+			count++
+		}
+	}
+	return count
+}
+
+func TestDiffLines(t *testing.T) {
+	data := []struct {
+		a, b    string
+		changes int
+	}{
+		{"", "", 0},
+		{"a\n", "a\n", 0},
+		{"", "a\nb\n", 2},
+		{"a\nb\n", "", 2},
+		{"a\nb\nc\n", "a\nc\n", 1},
+		{"a\nc\n", "a\nb\nc\n", 1},
+		{"a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n", 5},
+		{"a\nb\n", "a\nb", 2},
+	}
+	for _, d := range data {
+		edits, err := DiffLines(strings.NewReader(d.a), strings.NewReader(d.b), false)
+		if err != nil {
+			t.Fatalf("DiffLines(%q, %q): %v", d.a, d.b, err)
+		}
+		a, b := applyDiffEdits(edits)
+		if a != d.a || b != d.b {
+			t.Errorf("DiffLines(%q, %q): expected=%q, %q  actual=%q, %q",
+				d.a, d.b, d.a, d.b, a, b)
+		}
+		if actual := countDiffChanges(edits); actual != d.changes {
+			t.Errorf("DiffLines(%q, %q): expected_changes=%v  actual_changes=%v",
+				d.a, d.b, d.changes, actual)
+		}
+	}
+}
+
+func TestDiffLinesNumbers(t *testing.T) {
+	edits, err := DiffLines(strings.NewReader("a\nb\nc\n"), strings.NewReader("a\nx\nc\n"), false)
+	if err != nil {
+		t.Fatalf("DiffLines: %v", err)
+	}
+	expected := []DiffEdit{
+		{Op: DiffEqual, ALine: 1, BLine: 1, Text: "a\n"},
+		{Op: DiffDelete, ALine: 2, Text: "b\n"},
+		{Op: DiffInsert, BLine: 2, Text: "x\n"},
+		{Op: DiffEqual, ALine: 3, BLine: 3, Text: "c\n"},
+	}
+	if fmt.Sprint(edits) != fmt.Sprint(expected) {
+		t.Errorf("DiffLines: expected=%+v  actual=%+v", expected, edits)
+	}
+}
+
+func TestDiffLinesIgnoreEOL(t *testing.T) {
+	a := "a\r\nb\rc\n"
+	b := "a\nb\nc"
+	for _, ignoreEOL := range []bool{false, true} {
+		edits, err := DiffLines(strings.NewReader(a), strings.NewReader(b), ignoreEOL)
+		if err != nil {
+			t.Fatalf("DiffLines(ignoreEOL=%v): %v", ignoreEOL, err)
+		}
+		expected := 6
+		if ignoreEOL {
+			expected = 0
+		}
+		if actual := countDiffChanges(edits); actual != expected {
+			t.Errorf("DiffLines(ignoreEOL=%v): expected_changes=%v  actual_changes=%v",
+				ignoreEOL, expected, actual)
+		}
+	}
+}
+
+func TestDiffLinesRandom(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	randomText := func() string {
+		var sb strings.Builder
+		for n := rng.IntN(30); n > 0; n-- {
+			fmt.Fprintf(&sb, "%c\n", 'a'+rng.IntN(4))
+		}
+		return sb.String()
+	}
+	for i := 0; i < 200; i++ {
+		a, b := randomText(), randomText()
+		edits, err := DiffLines(strings.NewReader(a), strings.NewReader(b), false)
+		if err != nil {
+			t.Fatalf("DiffLines(%q, %q): %v", a, b, err)
+		}
+		actualA, actualB := applyDiffEdits(edits)
+		if actualA != a || actualB != b {
+			t.Fatalf("DiffLines(%q, %q): expected=%q, %q  actual=%q, %q",
+				a, b, a, b, actualA, actualB)
+		}
+	}
+}
+
+func TestDiffLinesError(t *testing.T) {
+	readErr := errors.New("read failed")
+	_, err := DiffLines(strings.NewReader("a\n"), &errorReader{err: readErr}, false)
+	if err != readErr {
+		t.Errorf("DiffLines: expected=%v  actual=%v", readErr, err)
+	}
+}
+
+func TestWriteUnifiedDiff(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13"
+	data := []struct {
+		context  int
+		expected string
+	}{
+		{
+			context: 1,
+			expected: "--- a\n+++ b\n" +
+				"@@ -2,3 +2,3 @@\n 2\n-3\n+three\n 4\n" +
+				"@@ -12 +12,2 @@\n 12\n+13\n\\ No newline at end of file\n",
+		},
+		{
+			context: 0,
+			expected: "--- a\n+++ b\n" +
+				"@@ -3 +3 @@\n-3\n+three\n" +
+				"@@ -12,0 +13 @@\n+13\n\\ No newline at end of file\n",
+		},
+		{
+			context: 5,
+			expected: "--- a\n+++ b\n" +
+				"@@ -1,12 +1,13 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+				" 7\n 8\n 9\n 10\n 11\n 12\n+13\n\\ No newline at end of file\n",
+		},
+	}
+	edits, err := DiffLines(strings.NewReader(a), strings.NewReader(b), false)
+	if err != nil {
+		t.Fatalf("DiffLines: %v", err)
+	}
+	for _, d := range data {
+		var sb strings.Builder
+		err = WriteUnifiedDiff(&sb, edits, "a", "b", d.context)
+		if err != nil {
+			t.Fatalf("WriteUnifiedDiff(context=%v): %v", d.context, err)
+		}
+		if sb.String() != d.expected {
+			t.Errorf("WriteUnifiedDiff(context=%v): expected=%q  actual=%q",
+				d.context, d.expected, sb.String())
+		}
+	}
+
+	// Identical inputs produce no output.
+	edits, err = DiffLines(strings.NewReader(a), strings.NewReader(a), false)
+	if err != nil {
+		t.Fatalf("DiffLines: %v", err)
+	}
+	var sb strings.Builder
+	err = WriteUnifiedDiff(&sb, edits, "a", "b", 3)
+	if err != nil {
+		t.Fatalf("WriteUnifiedDiff: %v", err)
+	}
+	if sb.Len() != 0 {
+		t.Errorf("WriteUnifiedDiff: expected=%q  actual=%q", "", sb.String())
+	}
+}
@@ -0,0 +1,148 @@
+package jrutil
+
+import (
+	"io"
+)
+
+// EOL identifies an end-of-line sequence.
+type EOL int
+
+const (
+	// EOLNone indicates the line has no EOL sequence which usually
+	// only happens for the last line of the input.
+	EOLNone EOL = iota
+
+	// EOLUnix is the Unix EOL sequence, "\n".
+	EOLUnix
+
+	// EOLDOS is the DOS EOL sequence, "\r\n".
+	EOLDOS
+
+	// EOLMac is the old Mac EOL sequence, "\r".
+	EOLMac
+)
+
+// String returns the name of the EOL sequence.
+func (e EOL) String() string {
+	switch e {
+	case EOLNone:
+		return "None"
+	case EOLUnix:
+		return "Unix"
+	case EOLDOS:
+		return "DOS"
+	case EOLMac:
+		return "Mac"
+	default:
+		return "Unknown"
+	}
+}
+
+// Sequence returns the characters that make up the EOL sequence.
+func (e EOL) Sequence() string {
+	switch e {
+	case EOLUnix:
+		return "\n"
+	case EOLDOS:
+		return "\r\n"
+	case EOLMac:
+		return "\r"
+	default:
+		return ""
+	}
+}
+
+// EOLOf returns the EOL sequence at the end of the line.
+func EOLOf(line string) EOL {
+	count := len(line)
+	if count >= 2 {
+		if line[count-2] == '\r' && line[count-1] == '\n' {
+			return EOLDOS
+		}
+	}
+	if count >= 1 {
+		switch line[count-1] {
+		case '\n':
+			return EOLUnix
+		case '\r':
+			return EOLMac
+		}
+	}
+	return EOLNone
+}
+
+// detectEOLSampleSize is the number of bytes sampled by DetectEOL().
+const detectEOLSampleSize = 64 * 1024
+
+// EOLStats holds the number of times each EOL sequence was found.
+type EOLStats struct {
+	Unix uint64
+	DOS  uint64
+	Mac  uint64
+}
+
+// Dominant returns the EOL sequence that was found most often.  Ties
+// are broken in favor of Unix, then DOS, then Mac.  If no EOL
+// sequences were found, EOLNone is returned.
+func (s EOLStats) Dominant() EOL {
+	switch {
+	case s.Unix == 0 && s.DOS == 0 && s.Mac == 0:
+		return EOLNone
+	case s.Unix >= s.DOS && s.Unix >= s.Mac:
+		return EOLUnix
+	case s.DOS >= s.Mac:
+		return EOLDOS
+	default:
+		return EOLMac
+	}
+}
+
+// Mixed returns true if more than one kind of EOL sequence was found.
+func (s EOLStats) Mixed() bool {
+	kinds := 0
+	for _, count := range []uint64{s.Unix, s.DOS, s.Mac} {
+		if count > 0 {
+			kinds++
+		}
+	}
+	return kinds > 1
+}
+
+// DetectEOL samples up to the first 64 KiB of r and counts the EOL
+// sequences that it finds.  Use [EOLStats.Dominant()] and
+// [EOLStats.Mixed()] to interpret the result.  Because r is consumed,
+// callers that want to read the same text again must either seek back
+// to the start or sample a copy, for example by using io.TeeReader or
+// bufio.Reader.Peek().
+func DetectEOL(r io.Reader) (EOLStats, error) {
+	var stats EOLStats
+
+	// Read the sample plus one byte so a "\r" at the end of the
+	// sample can be distinguished from "\r\n".
+	buf := make([]byte, detectEOLSampleSize+1)
+	n, err := io.ReadFull(r, buf)
+	atEOF := err == io.EOF || err == io.ErrUnexpectedEOF
+	if err != nil && !atEOF {
+		return stats, err
+	}
+	buf = buf[:n]
+
+	// Count the EOL sequences in the sample.
+	for len(buf) > 0 {
+		n := findEOL(buf, atEOF)
+		if n < 0 {
+			break
+		}
+		switch EOLOf(string(buf[max(0, n-2):n])) {
+		case EOLUnix:
+			stats.Unix++
+		case EOLDOS:
+			stats.DOS++
+		case EOLMac:
+			stats.Mac++
+		}
+		buf = buf[n:]
+	}
+
+	return stats, nil
+}
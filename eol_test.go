@@ -0,0 +1,112 @@
+package jrutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEOLOf(t *testing.T) {
+	data := []struct {
+		line     string
+		expected EOL
+	}{
+		{line: "", expected: EOLNone},
+		{line: "foo", expected: EOLNone},
+		{line: "\r", expected: EOLMac},
+		{line: "\n", expected: EOLUnix},
+		{line: "\r\n", expected: EOLDOS},
+		{line: "foo\r", expected: EOLMac},
+		{line: "foo\n", expected: EOLUnix},
+		{line: "foo\r\n", expected: EOLDOS},
+		{line: "foo\n\r", expected: EOLMac},
+	}
+
+	for _, d := range data {
+		actual := EOLOf(d.line)
+		if actual != d.expected {
+			t.Errorf("EOLOf(%q): expected=%v  actual=%v",
+				d.line, d.expected, actual)
+		}
+		if actual.Sequence() != d.line[len(StripEOL(d.line)):] {
+			t.Errorf("EOLOf(%q): unexpected sequence %q",
+				d.line, actual.Sequence())
+		}
+	}
+}
+
+func TestDetectEOL(t *testing.T) {
+	data := []struct {
+		text     string
+		dominant EOL
+		mixed    bool
+		stats    EOLStats
+	}{
+		{
+			text:     "",
+			dominant: EOLNone,
+			mixed:    false,
+		},
+		{
+			text:     "foo",
+			dominant: EOLNone,
+			mixed:    false,
+		},
+		{
+			text:     "foo\nbar\n",
+			dominant: EOLUnix,
+			mixed:    false,
+			stats:    EOLStats{Unix: 2},
+		},
+		{
+			text:     "foo\r\nbar\r\nbaz\n",
+			dominant: EOLDOS,
+			mixed:    true,
+			stats:    EOLStats{Unix: 1, DOS: 2},
+		},
+		{
+			text:     "foo\rbar\rbaz\r",
+			dominant: EOLMac,
+			mixed:    false,
+			stats:    EOLStats{Mac: 3},
+		},
+		{
+			text:     "foo\rbar\nbaz\r\n",
+			dominant: EOLUnix,
+			mixed:    true,
+			stats:    EOLStats{Unix: 1, DOS: 1, Mac: 1},
+		},
+	}
+
+	for _, d := range data {
+		stats, err := DetectEOL(strings.NewReader(d.text))
+		if err != nil {
+			t.Errorf("DetectEOL(%q): %v", d.text, err)
+			continue
+		}
+		if stats != d.stats {
+			t.Errorf("DetectEOL(%q): expected=%+v  actual=%+v",
+				d.text, d.stats, stats)
+		}
+		if stats.Dominant() != d.dominant {
+			t.Errorf("DetectEOL(%q): expected_dominant=%v  actual_dominant=%v",
+				d.text, d.dominant, stats.Dominant())
+		}
+		if stats.Mixed() != d.mixed {
+			t.Errorf("DetectEOL(%q): expected_mixed=%v  actual_mixed=%v",
+				d.text, d.mixed, stats.Mixed())
+		}
+	}
+}
+
+// TestDetectEOLSampleBoundary tests that "\r\n" split across the end
+// of the sample is still recognized as a DOS EOL.
+func TestDetectEOLSampleBoundary(t *testing.T) {
+	text := strings.Repeat("x", detectEOLSampleSize-1) + "\r\n" + "more\r\n"
+	stats, err := DetectEOL(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("DetectEOL: %v", err)
+	}
+	if stats != (EOLStats{DOS: 1}) {
+		t.Errorf("DetectEOL: expected=%+v  actual=%+v", EOLStats{DOS: 1}, stats)
+	}
+}
@@ -0,0 +1,103 @@
+package jrutil
+
+import (
+	"bytes"
+	"io"
+)
+
+// EOLWriter is an io.Writer that converts every "\r", "\n", and
+// "\r\n" EOL sequence written to it into a single target EOL sequence
+// before writing to the underlying io.Writer.  This is useful for
+// normalizing text with mixed EOL sequences.
+//
+// Because a "\r" at the end of one Write() call might be followed by
+// a "\n" at the start of the next, a trailing "\r" is held back until
+// the next byte arrives.  Call Close() when done writing so that a
+// trailing "\r" at the very end of the text is converted.
+type EOLWriter struct {
+	w         io.Writer
+	eol       []byte
+	buf       []byte
+	pendingCR bool
+}
+
+// NewEOLWriter returns a new EOLWriter that writes to w converting all
+// EOL sequences to eol.  If eol is EOLNone, EOL sequences are removed.
+// For best performance when making many small writes, w should be a
+// bufio.Writer.
+func NewEOLWriter(w io.Writer, eol EOL) *EOLWriter {
+	return &EOLWriter{
+		w:   w,
+		eol: []byte(eol.Sequence()),
+	}
+}
+
+// Write converts the EOL sequences in p and writes the result to the
+// underlying io.Writer.  On success, len(p) is returned.  If the
+// underlying io.Writer fails, zero is returned with the error because
+// the number of bytes written after conversion does not correspond to
+// the number of bytes in p.
+func (w *EOLWriter) Write(p []byte) (int, error) {
+	count := len(p)
+	w.buf = w.buf[:0]
+
+	// Finish the "\r" from the previous call which is either "\r" or
+	// "\r\n" depending on the next byte.
+	if w.pendingCR && len(p) > 0 {
+		w.pendingCR = false
+		w.buf = append(w.buf, w.eol...)
+		if p[0] == '\n' {
+			p = p[1:]
+		}
+	}
+
+	// Convert the EOL sequences.
+	for len(p) > 0 {
+		i := bytes.IndexAny(p, "\r\n")
+		if i < 0 {
+			w.buf = append(w.buf, p...)
+			break
+		}
+		w.buf = append(w.buf, p[:i]...)
+
+		// Check for "\n" EOL.
+		if p[i] == '\n' {
+			w.buf = append(w.buf, w.eol...)
+			p = p[i+1:]
+			continue
+		}
+
+		// A trailing "\r" must wait for the next call.
+		if i+1 == len(p) {
+			w.pendingCR = true
+			break
+		}
+
+		// Check for "\r\n" or "\r" EOL.
+		w.buf = append(w.buf, w.eol...)
+		if p[i+1] == '\n' {
+			p = p[i+2:]
+		} else {
+			p = p[i+1:]
+		}
+	}
+
+	// Write the converted text.
+	_, err := w.w.Write(w.buf)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Close converts a trailing "\r" if one is being held back.  It does
+// not close the underlying io.Writer.
+func (w *EOLWriter) Close() error {
+	if !w.pendingCR {
+		return nil
+	}
+	w.pendingCR = false
+	_, err := w.w.Write(w.eol)
+	return err
+}
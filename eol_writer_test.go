@@ -0,0 +1,78 @@
+package jrutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEOLWriter(t *testing.T) {
+	data := []struct {
+		chunks   []string
+		eol      EOL
+		expected string
+	}{
+		{
+			chunks:   []string{""},
+			eol:      EOLUnix,
+			expected: "",
+		},
+		{
+			chunks:   []string{"foo\rbar\nbaz\r\nqux"},
+			eol:      EOLUnix,
+			expected: "foo\nbar\nbaz\nqux",
+		},
+		{
+			chunks:   []string{"foo\rbar\nbaz\r\nqux"},
+			eol:      EOLDOS,
+			expected: "foo\r\nbar\r\nbaz\r\nqux",
+		},
+		{
+			chunks:   []string{"foo\rbar\nbaz\r\nqux"},
+			eol:      EOLMac,
+			expected: "foo\rbar\rbaz\rqux",
+		},
+		{
+			chunks:   []string{"foo\rbar\nbaz\r\nqux"},
+			eol:      EOLNone,
+			expected: "foobarbazqux",
+		},
+		{
+			chunks:   []string{"foo\r", "\nbar\r", "baz\r"},
+			eol:      EOLUnix,
+			expected: "foo\nbar\nbaz\n",
+		},
+		{
+			chunks:   []string{"foo\r", "", "\n", "\r", "\r", "\n"},
+			eol:      EOLDOS,
+			expected: "foo\r\n\r\n\r\n",
+		},
+		{
+			chunks:   []string{"\n\r", "\n\n"},
+			eol:      EOLMac,
+			expected: "\r\r\r",
+		},
+	}
+
+	for _, d := range data {
+		var b strings.Builder
+		w := NewEOLWriter(&b, d.eol)
+		for _, chunk := range d.chunks {
+			n, err := w.Write([]byte(chunk))
+			if err != nil {
+				t.Errorf("EOLWriter.Write(%q): %v", chunk, err)
+			}
+			if n != len(chunk) {
+				t.Errorf("EOLWriter.Write(%q): expected_n=%v  actual_n=%v",
+					chunk, len(chunk), n)
+			}
+		}
+		err := w.Close()
+		if err != nil {
+			t.Errorf("EOLWriter.Close: %v", err)
+		}
+		if b.String() != d.expected {
+			t.Errorf("EOLWriter(%q, %v): expected=%q  actual=%q",
+				d.chunks, d.eol, d.expected, b.String())
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package jrutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrFieldIndex is the error wrapped by a *FieldError when a field
+// index is out of range.
+var ErrFieldIndex = errors.New("field index out of range")
+
+// ErrFieldName is the error wrapped by a *FieldError when there is no
+// field with the requested name.
+var ErrFieldName = errors.New("no such field")
+
+// ErrFieldQuote is the error wrapped by a *FieldError when a quoted
+// field is malformed.
+var ErrFieldQuote = errors.New("malformed quoted field")
+
+// FieldError is the error returned when a field cannot be split or
+// accessed.  It records the line number so errors.As() can recover it
+// for diagnostics.
+type FieldError struct {
+
+	// Line is the 1-based line number.
+	Line uint64
+
+	// Index is the 0-based index of the field or -1 if the field was
+	// accessed by name or the error is not about a particular field.
+	Index int
+
+	// Name is the name of the field if it was accessed by name.
+	Name string
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the error message prefixed by the line number and the
+// field.
+func (e *FieldError) Error() string {
+	switch {
+	case e.Name != "":
+		return fmt.Sprintf("line %v: field %q: %v", e.Line, e.Name, e.Err)
+	case e.Index >= 0:
+		return fmt.Sprintf("line %v: field %v: %v", e.Line, e.Index, e.Err)
+	default:
+		return fmt.Sprintf("line %v: %v", e.Line, e.Err)
+	}
+}
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fieldSeparatorKind identifies how a FieldSeparator splits lines.
+type fieldSeparatorKind int
+
+const (
+	whitespaceSeparator fieldSeparatorKind = iota
+	charSeparator
+	regexpSeparator
+)
+
+// FieldSeparator specifies how [ForEachFields()] splits lines into
+// fields.  The zero value splits on runs of whitespace.
+type FieldSeparator struct {
+	kind fieldSeparatorKind
+	char rune
+	re   *regexp.Regexp
+}
+
+// WhitespaceSeparator returns a FieldSeparator that splits lines on
+// runs of whitespace ignoring leading and trailing whitespace like
+// strings.Fields() does.
+func WhitespaceSeparator() FieldSeparator {
+	return FieldSeparator{kind: whitespaceSeparator}
+}
+
+// TabSeparator returns a FieldSeparator that splits lines on each tab
+// for TSV files.
+func TabSeparator() FieldSeparator {
+	return CharSeparator('\t')
+}
+
+// CharSeparator returns a FieldSeparator that splits lines on each
+// occurrence of c, for example ',' for CSV files.
+func CharSeparator(c rune) FieldSeparator {
+	return FieldSeparator{kind: charSeparator, char: c}
+}
+
+// RegexpSeparator returns a FieldSeparator that splits lines on each
+// match of re.
+func RegexpSeparator(re *regexp.Regexp) FieldSeparator {
+	return FieldSeparator{kind: regexpSeparator, re: re}
+}
+
+// FieldOptions holds the options for [ForEachFields()].
+type FieldOptions struct {
+
+	// Separator specifies how lines are split into fields.
+	Separator FieldSeparator
+
+	// Quote enables simple CSV-style quoting when it is not zero.  A
+	// field that starts with Quote extends to the matching Quote and
+	// may contain the separator.  Two Quotes in a row inside a quoted
+	// field stand for one Quote.  Quoted fields cannot span lines.
+	// Quoting only applies to CharSeparator() and TabSeparator().
+	Quote rune
+
+	// Header causes the first line to be treated as the names of the
+	// fields instead of being passed to the callback.
+	Header bool
+
+	// Strict has the same meaning as the corresponding parameter of
+	// ForEachLine().
+	Strict bool
+}
+
+// FieldRecord holds the fields of one line passed to the callback of
+// [ForEachFields()].
+type FieldRecord struct {
+
+	// Line is the 1-based line number.
+	Line uint64
+
+	// Fields holds the fields of the line.
+	Fields []string
+
+	// names maps the name of each field to its index.
+	names map[string]int
+}
+
+// Len returns the number of fields.
+func (r *FieldRecord) Len() int {
+	return len(r.Fields)
+}
+
+// Field returns the field at the 0-based index i.  If i is out of
+// range, a *FieldError wrapping ErrFieldIndex is returned.
+func (r *FieldRecord) Field(i int) (string, error) {
+	if i < 0 || i >= len(r.Fields) {
+		return "", &FieldError{Line: r.Line, Index: i, Err: ErrFieldIndex}
+	}
+	return r.Fields[i], nil
+}
+
+// FieldByName returns the field with the given name as specified by
+// the header.  If there is no such field in the header, a *FieldError
+// wrapping ErrFieldName is returned.  If the line does not have the
+// field, a *FieldError wrapping ErrFieldIndex is returned.
+func (r *FieldRecord) FieldByName(name string) (string, error) {
+	i, ok := r.names[name]
+	if !ok {
+		return "", &FieldError{Line: r.Line, Index: -1, Name: name, Err: ErrFieldName}
+	}
+	if i >= len(r.Fields) {
+		return "", &FieldError{Line: r.Line, Index: -1, Name: name, Err: ErrFieldIndex}
+	}
+	return r.Fields[i], nil
+}
+
+// ForEachFields reads each line of text in r using [ForEachLine()],
+// splits it into fields as specified by opts, and invokes fn with the
+// fields.  If opts is nil, lines are split on runs of whitespace.  The
+// EOL sequence is always stripped before splitting.
+//
+// The *FieldRecord passed to fn is only valid until fn returns.  To
+// receive the next line of text, fn must return (true, nil).  If fn
+// returns an error, it is forwarded to the caller.  If a line cannot
+// be split because of malformed quoting, a *FieldError wrapping
+// ErrFieldQuote is returned.
+func ForEachFields(
+	r io.Reader,
+	opts *FieldOptions,
+	fn func(*FieldRecord) (bool, error),
+) error {
+	var o FieldOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	var record FieldRecord
+	err := ForEachLine(r, true, o.Strict, func(line string) (bool, error) {
+		var err error
+
+		// Split the line into fields.
+		record.Line++
+		record.Fields, err = splitFields(line, &o, record.Fields[:0])
+		if err != nil {
+			return false, &FieldError{Line: record.Line, Index: -1, Err: err}
+		}
+
+		// Handle the header.
+		if o.Header && record.Line == 1 {
+			record.names = map[string]int{}
+			for i, name := range record.Fields {
+				if _, ok := record.names[name]; !ok {
+					record.names[name] = i
+				}
+			}
+			record.Fields = nil
+			return true, nil
+		}
+
+		return fn(&record)
+	})
+
+	return err
+}
+
+// splitFields splits the line into fields appending them to fields.
+func splitFields(line string, o *FieldOptions, fields []string) ([]string, error) {
+	switch o.Separator.kind {
+	case charSeparator:
+		if o.Quote != 0 {
+			return splitQuotedFields(line, o.Separator.char, o.Quote, fields)
+		}
+		return append(fields, strings.Split(line, string(o.Separator.char))...), nil
+	case regexpSeparator:
+		return append(fields, o.Separator.re.Split(line, -1)...), nil
+	default:
+		return append(fields, strings.Fields(line)...), nil
+	}
+}
+
+// splitQuotedFields splits the line on sep honoring quoted fields.
+func splitQuotedFields(line string, sep rune, quote rune, fields []string) ([]string, error) {
+	sepLen := utf8.RuneLen(sep)
+	quoteLen := utf8.RuneLen(quote)
+	for {
+
+		// Handle an unquoted field.
+		if !strings.HasPrefix(line, string(quote)) {
+			i := strings.IndexRune(line, sep)
+			if i < 0 {
+				return append(fields, line), nil
+			}
+			fields = append(fields, line[:i])
+			line = line[i+sepLen:]
+			continue
+		}
+
+		// Handle a quoted field.
+		var field strings.Builder
+		line = line[quoteLen:]
+		for {
+			i := strings.IndexRune(line, quote)
+			if i < 0 {
+				return fields, ErrFieldQuote
+			}
+			field.WriteString(line[:i])
+			line = line[i+quoteLen:]
+			if strings.HasPrefix(line, string(quote)) {
+				field.WriteRune(quote)
+				line = line[quoteLen:]
+				continue
+			}
+			break
+		}
+		fields = append(fields, field.String())
+
+		// The closing quote must be followed by the separator or the
+		// end of the line.
+		if line == "" {
+			return fields, nil
+		}
+		if !strings.HasPrefix(line, string(sep)) {
+			return fields, ErrFieldQuote
+		}
+		line = line[sepLen:]
+	}
+}
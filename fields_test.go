@@ -0,0 +1,148 @@
+package jrutil
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestForEachFields(t *testing.T) {
+	data := []struct {
+		text     string
+		opts     *FieldOptions
+		expected [][]string
+	}{
+		{
+			text:     "",
+			opts:     nil,
+			expected: [][]string{},
+		},
+		{
+			text:     "  foo bar\t baz \nqux\n\n",
+			opts:     nil,
+			expected: [][]string{{"foo", "bar", "baz"}, {"qux"}, {}},
+		},
+		{
+			text:     "foo\tbar\t\tbaz\r\n\tqux\r\n",
+			opts:     &FieldOptions{Separator: TabSeparator()},
+			expected: [][]string{{"foo", "bar", "", "baz"}, {"", "qux"}},
+		},
+		{
+			text:     "a,b,c\n,\n",
+			opts:     &FieldOptions{Separator: CharSeparator(',')},
+			expected: [][]string{{"a", "b", "c"}, {"", ""}},
+		},
+		{
+			text:     "a;;b;c\n",
+			opts:     &FieldOptions{Separator: RegexpSeparator(regexp.MustCompile(";+"))},
+			expected: [][]string{{"a", "b", "c"}},
+		},
+		{
+			text: `a,"b,c","say ""hi""",""` + "\n" + `"",x,"y"` + "\n",
+			opts: &FieldOptions{
+				Separator: CharSeparator(','),
+				Quote:     '"',
+			},
+			expected: [][]string{{"a", "b,c", `say "hi"`, ""}, {"", "x", "y"}},
+		},
+	}
+
+	for _, d := range data {
+		actual := [][]string{}
+		err := ForEachFields(strings.NewReader(d.text), d.opts,
+			func(record *FieldRecord) (bool, error) {
+				actual = append(actual, slices.Clone(record.Fields))
+				return true, nil
+			})
+		if err != nil {
+			t.Errorf("ForEachFields(%q): %v", d.text, err)
+			continue
+		}
+		if !slices.EqualFunc(actual, d.expected, slices.Equal) {
+			t.Errorf("ForEachFields(%q): expected=%q  actual=%q",
+				d.text, d.expected, actual)
+		}
+	}
+}
+
+// TestForEachFieldsHeader tests accessing fields by name and the
+// errors returned for missing fields.
+func TestForEachFieldsHeader(t *testing.T) {
+	text := "name\tage\nalice\t30\nbob\n"
+	opts := &FieldOptions{
+		Separator: TabSeparator(),
+		Header:    true,
+	}
+
+	var names []string
+	var errs []error
+	err := ForEachFields(strings.NewReader(text), opts,
+		func(record *FieldRecord) (bool, error) {
+			name, err := record.FieldByName("name")
+			if err != nil {
+				return false, err
+			}
+			names = append(names, name)
+			_, err = record.FieldByName("age")
+			errs = append(errs, err)
+			return true, nil
+		})
+	if err != nil {
+		t.Fatalf("ForEachFields: %v", err)
+	}
+	if !slices.Equal(names, []string{"alice", "bob"}) {
+		t.Errorf("ForEachFields: unexpected names %q", names)
+	}
+
+	// The second record is missing its age.
+	var fieldErr *FieldError
+	if errs[0] != nil {
+		t.Errorf("FieldByName: %v", errs[0])
+	}
+	if !errors.As(errs[1], &fieldErr) || !errors.Is(errs[1], ErrFieldIndex) {
+		t.Fatalf("FieldByName: expected *FieldError  actual=%v", errs[1])
+	}
+	if fieldErr.Line != 3 || fieldErr.Name != "age" {
+		t.Errorf("FieldByName: unexpected error %v", fieldErr)
+	}
+}
+
+// TestForEachFieldsErrors tests the errors returned for bad indexes,
+// bad names, and malformed quoting.
+func TestForEachFieldsErrors(t *testing.T) {
+	var fieldErr *FieldError
+
+	// Bad index and bad name.
+	err := ForEachFields(strings.NewReader("foo bar\n"), nil,
+		func(record *FieldRecord) (bool, error) {
+			_, err := record.Field(2)
+			if !errors.Is(err, ErrFieldIndex) {
+				t.Errorf("Field: expected=%v  actual=%v", ErrFieldIndex, err)
+			}
+			_, err = record.FieldByName("foo")
+			return false, err
+		})
+	if !errors.As(err, &fieldErr) || !errors.Is(err, ErrFieldName) {
+		t.Errorf("FieldByName: expected *FieldError  actual=%v", err)
+	}
+
+	// Malformed quoting.
+	opts := &FieldOptions{Separator: CharSeparator(','), Quote: '"'}
+	for _, text := range []string{"ok\n\"a", "ok\n\"a\"b"} {
+		err = ForEachFields(strings.NewReader(text), opts,
+			func(record *FieldRecord) (bool, error) {
+				return true, nil
+			})
+		if !errors.As(err, &fieldErr) || !errors.Is(err, ErrFieldQuote) {
+			t.Errorf("ForEachFields(%q): expected *FieldError  actual=%v",
+				text, err)
+			continue
+		}
+		if fieldErr.Line != 2 {
+			t.Errorf("ForEachFields(%q): expected_line=2  actual_line=%v",
+				text, fieldErr.Line)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package jrutil
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileInputOptions holds the options for [ForEachLineInFiles()].
+type FileInputOptions struct {
+
+	// StripEOL and Strict have the same meaning as the corresponding
+	// parameters of ForEachLine().
+	StripEOL bool
+	Strict   bool
+
+	// InPlace enables in-place editing.  Each file is replaced by
+	// whatever the callback writes to FileLine.Out.
+	InPlace bool
+
+	// Backup is the suffix appended to the name of each file to make
+	// a backup of the original when editing in place.  If it is
+	// empty, no backup is made.
+	Backup string
+
+	// Stdin is read when the file name is "-".  If it is nil,
+	// os.Stdin is used.
+	Stdin io.Reader
+}
+
+// FileLine is a line of text passed to the callback of
+// [ForEachLineInFiles()] along with where it came from.
+type FileLine struct {
+
+	// Line is the line of text.
+	Line string
+
+	// Name is the name of the file or "-" for stdin.
+	Name string
+
+	// LineNumber is the 1-based line number within the file.
+	LineNumber uint64
+
+	// TotalLineNumber is the 1-based line number across all files.
+	TotalLineNumber uint64
+
+	// Out is where the replacement text for the line should be
+	// written when editing in place.  Lines that are not written to
+	// Out are deleted.  When not editing in place, Out is nil.
+	Out io.Writer
+}
+
+// FileError is the error returned by [ForEachLineInFiles()].  It
+// records the name of the file that was being processed.
+type FileError struct {
+	Name string
+	Err  error
+}
+
+// Error returns the error message prefixed by the file name.
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Name, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// ForEachLineInFiles invokes fn for each line of text in each of the
+// files in paths treating them as one logical stream of lines much
+// like Python's fileinput module.  The file name "-" refers to stdin.
+// Files are opened with [OpenLines()] so compressed files are
+// transparently decompressed.  If opts is nil, the default options
+// are used.
+//
+// If opts.InPlace is true, each file is edited in place by replacing
+// it with whatever fn writes to FileLine.Out.  The replacement is
+// written to a temporary file in the same directory which is then
+// atomically renamed over the original.  If fn stops the iteration
+// early, the rest of the current file is copied unchanged.  If an
+// error occurs, the current file is left unchanged.  Files being
+// edited in place are not decompressed, and stdin cannot be edited in
+// place.
+//
+// To receive the next line of text, fn must return (true, nil).  Any
+// error, whether from reading the files or returned by fn, is wrapped
+// in a *[FileError] that records the name of the file.
+func ForEachLineInFiles(
+	paths []string,
+	opts *FileInputOptions,
+	fn func(FileLine) (bool, error),
+) error {
+	var o FileInputOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Stdin == nil {
+		o.Stdin = os.Stdin
+	}
+
+	var total uint64
+	for _, path := range paths {
+		var more bool
+		var err error
+		if o.InPlace {
+			more, err = editLinesInPlace(path, &o, &total, fn)
+		} else {
+			more, err = forEachLineInInput(path, &o, &total, fn)
+		}
+		if err != nil {
+			return &FileError{Name: path, Err: err}
+		}
+		if !more {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// forEachLineInInput invokes fn for each line of text in the file at
+// path.  It returns false if fn stopped the iteration.
+func forEachLineInInput(
+	path string,
+	o *FileInputOptions,
+	total *uint64,
+	fn func(FileLine) (bool, error),
+) (more bool, err error) {
+
+	// Open the file.
+	r := o.Stdin
+	if path != "-" {
+		var rc io.ReadCloser
+		rc, err = OpenLines(path)
+		if err != nil {
+			return false, err
+		}
+		defer func() {
+			closeErr := rc.Close()
+			if err == nil {
+				err = closeErr
+			}
+		}()
+		r = rc
+	}
+
+	// Process each line.
+	more = true
+	var number uint64
+	err = ForEachLine(r, o.StripEOL, o.Strict,
+		func(line string) (bool, error) {
+			number++
+			*total++
+			var fnErr error
+			more, fnErr = fn(FileLine{
+				Line:            line,
+				Name:            path,
+				LineNumber:      number,
+				TotalLineNumber: *total,
+			})
+			return more, fnErr
+		})
+
+	return more, err
+}
+
+// editLinesInPlace invokes fn for each line of text in the file at
+// path replacing the file with whatever fn writes to FileLine.Out.  It
+// returns false if fn stopped the iteration.
+func editLinesInPlace(
+	path string,
+	o *FileInputOptions,
+	total *uint64,
+	fn func(FileLine) (bool, error),
+) (more bool, err error) {
+
+	// Stdin cannot be edited in place.
+	if path == "-" {
+		return false, errors.New("cannot edit stdin in place")
+	}
+
+	// Open the file.
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	// Create the temporary file that will replace the original.
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	// Process each line.
+	br := bufio.NewReader(f)
+	bw := bufio.NewWriter(tmp)
+	more = true
+	var number uint64
+	err = ForEachLine(br, o.StripEOL, o.Strict,
+		func(line string) (bool, error) {
+			number++
+			*total++
+			var fnErr error
+			more, fnErr = fn(FileLine{
+				Line:            line,
+				Name:            path,
+				LineNumber:      number,
+				TotalLineNumber: *total,
+				Out:             bw,
+			})
+			return more, fnErr
+		})
+	if err != nil {
+		return false, err
+	}
+
+	// If fn stopped early, keep the rest of the file.
+	if !more {
+		_, err = io.Copy(bw, br)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// Finish writing the temporary file.
+	err = bw.Flush()
+	if err != nil {
+		return false, err
+	}
+	err = tmp.Chmod(info.Mode().Perm())
+	if err != nil {
+		return false, err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return false, err
+	}
+
+	// Replace the original.  The backup is made without moving the
+	// original so path always exists even if the rename fails.
+	if o.Backup != "" {
+		err = backupFile(path, path+o.Backup, info.Mode().Perm())
+		if err != nil {
+			return false, err
+		}
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return false, err
+	}
+
+	return more, nil
+}
+
+// backupFile makes backup a copy of the file at path replacing any
+// existing backup.  It uses a hard link when possible and copies the
+// file otherwise.
+func backupFile(path string, backup string, perm os.FileMode) (err error) {
+	err = os.Remove(backup)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if os.Link(path, backup) == nil {
+		return nil
+	}
+
+	// Hard links are not supported so copy the file.
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := out.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(backup)
+		}
+	}()
+	_, err = io.Copy(out, in)
+	return err
+}
@@ -0,0 +1,180 @@
+package jrutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeTestFiles writes each file in files to dir and returns their
+// paths.
+func writeTestFiles(t *testing.T, dir string, files ...string) []string {
+	var paths []string
+	for i, content := range files {
+		path := filepath.Join(dir, fmt.Sprintf("file%v.txt", i+1))
+		err := os.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestForEachLineInFiles(t *testing.T) {
+	dir := t.TempDir()
+	paths := writeTestFiles(t, dir, "foo\nbar\n", "baz")
+	paths = append(paths, "-")
+
+	opts := &FileInputOptions{
+		StripEOL: true,
+		Stdin:    strings.NewReader("qux\nquux\n"),
+	}
+	var actual []string
+	err := ForEachLineInFiles(paths, opts, func(fl FileLine) (bool, error) {
+		actual = append(actual, fmt.Sprintf("%v:%v:%v:%v",
+			filepath.Base(fl.Name), fl.LineNumber, fl.TotalLineNumber, fl.Line))
+		return true, nil
+	})
+	if err != nil {
+		t.Errorf("ForEachLineInFiles: %v", err)
+	}
+	expected := []string{
+		"file1.txt:1:1:foo",
+		"file1.txt:2:2:bar",
+		"file2.txt:1:3:baz",
+		"-:1:4:qux",
+		"-:2:5:quux",
+	}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("ForEachLineInFiles: expected=%q  actual=%q", expected, actual)
+	}
+}
+
+// TestForEachLineInFilesError tests that errors report which file
+// they came from.
+func TestForEachLineInFilesError(t *testing.T) {
+	dir := t.TempDir()
+	paths := writeTestFiles(t, dir, "foo\n", "bar\n")
+	errExpected := errors.New("bad line")
+
+	err := ForEachLineInFiles(paths, nil, func(fl FileLine) (bool, error) {
+		if fl.Line == "bar\n" {
+			return false, errExpected
+		}
+		return true, nil
+	})
+	var fileErr *FileError
+	if !errors.As(err, &fileErr) || !errors.Is(err, errExpected) {
+		t.Fatalf("ForEachLineInFiles: expected *FileError  actual=%v", err)
+	}
+	if fileErr.Name != paths[1] {
+		t.Errorf("ForEachLineInFiles: expected=%v  actual=%v",
+			paths[1], fileErr.Name)
+	}
+
+	// Missing files are reported too.
+	missing := filepath.Join(dir, "missing.txt")
+	err = ForEachLineInFiles([]string{missing}, nil,
+		func(fl FileLine) (bool, error) {
+			return true, nil
+		})
+	if !errors.As(err, &fileErr) || fileErr.Name != missing {
+		t.Errorf("ForEachLineInFiles: expected *FileError  actual=%v", err)
+	}
+}
+
+func TestForEachLineInFilesInPlace(t *testing.T) {
+	dir := t.TempDir()
+	paths := writeTestFiles(t, dir, "foo\nbar\n", "baz\r\nqux\r\n", "keep\nthese\n")
+
+	opts := &FileInputOptions{
+		InPlace: true,
+		Backup:  ".bak",
+	}
+	err := ForEachLineInFiles(paths, opts, func(fl FileLine) (bool, error) {
+		// Stop in the middle of the third file.
+		if fl.Line == "keep\n" {
+			_, err := fl.Out.Write([]byte("KEPT\n"))
+			return false, err
+		}
+		// Delete "bar" and upper-case everything else.
+		if fl.Line != "bar\n" {
+			_, err := fl.Out.Write([]byte(strings.ToUpper(fl.Line)))
+			return true, err
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachLineInFiles: %v", err)
+	}
+
+	expected := map[string]string{
+		paths[0]:          "FOO\n",
+		paths[0] + ".bak": "foo\nbar\n",
+		paths[1]:          "BAZ\r\nQUX\r\n",
+		paths[1] + ".bak": "baz\r\nqux\r\n",
+		paths[2]:          "KEPT\nthese\n",
+		paths[2] + ".bak": "keep\nthese\n",
+	}
+	for path, e := range expected {
+		actual, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("ReadFile: %v", err)
+			continue
+		}
+		if string(actual) != e {
+			t.Errorf("ForEachLineInFiles(%v): expected=%q  actual=%q",
+				filepath.Base(path), e, actual)
+		}
+	}
+
+	// No temporary files should be left behind.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != len(expected) {
+		t.Errorf("ForEachLineInFiles: unexpected files in %v", entries)
+	}
+}
+
+// TestForEachLineInFilesInPlaceExistingBackup tests that an existing
+// backup is replaced.
+func TestForEachLineInFilesInPlaceExistingBackup(t *testing.T) {
+	dir := t.TempDir()
+	paths := writeTestFiles(t, dir, "new\n")
+	err := os.WriteFile(paths[0]+".bak", []byte("old backup\n"), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	opts := &FileInputOptions{InPlace: true, Backup: ".bak"}
+	err = ForEachLineInFiles(paths, opts, func(fl FileLine) (bool, error) {
+		_, err := fl.Out.Write([]byte("edited\n"))
+		return true, err
+	})
+	if err != nil {
+		t.Fatalf("ForEachLineInFiles: %v", err)
+	}
+
+	expected := map[string]string{
+		paths[0]:          "edited\n",
+		paths[0] + ".bak": "new\n",
+	}
+	for path, e := range expected {
+		actual, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("ReadFile: %v", err)
+			continue
+		}
+		if string(actual) != e {
+			t.Errorf("ForEachLineInFiles(%v): expected=%q  actual=%q",
+				filepath.Base(path), e, actual)
+		}
+	}
+}
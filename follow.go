@@ -0,0 +1,272 @@
+package jrutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// defaultFollowPollInterval is how often FollowLines() checks for
+// changes to the file by default.
+const defaultFollowPollInterval = 250 * time.Millisecond
+
+// followBufferSize is the size of the buffer FollowLines() uses to
+// read from the file.
+const followBufferSize = 32 * 1024
+
+// FollowOptions holds the options for [FollowLines()].
+type FollowOptions struct {
+
+	// PollInterval is how often the file is checked for changes.  If
+	// it is zero, the file is checked every 250ms.
+	PollInterval time.Duration
+
+	// FromStart causes FollowLines() to start with the first line of
+	// the file instead of starting at the end of the file.
+	FromStart bool
+
+	// StripEOL causes the EOL sequences to be stripped from each
+	// line before the callback is invoked.
+	StripEOL bool
+}
+
+// follower holds the state of FollowLines().
+type follower struct {
+	path    string
+	opts    FollowOptions
+	fn      func(string) (bool, error)
+	f       *os.File
+	offset  int64  // offset in f of the end of pending
+	pending []byte // partial line waiting for its EOL
+	buf     []byte
+}
+
+// FollowLines invokes fn for each line of text appended to the file
+// at path much like "tail -F" does.  The file is polled for changes
+// which makes FollowLines() work with any file system.  By default,
+// only lines appended after FollowLines() is called are passed to fn.
+// Set opts.FromStart to start with the first line of the file.  If
+// opts is nil, the default options are used.
+//
+// Only complete lines are passed to fn.  A partial line at the end of
+// the file is held until its EOL sequence arrives.  DOS, Mac, and
+// UNIX EOL sequences are recognized as they are by [ReadLine()], but
+// because the next byte might be '\n', a line that ends in '\r' is
+// not passed to fn until the next byte is written.
+//
+// If the file does not exist, FollowLines() waits for it to be
+// created.  If the file is truncated, FollowLines() starts over at the
+// beginning of the file.  If the file is rotated, meaning path now
+// refers to a different file, FollowLines() finishes reading the old
+// file and then starts at the beginning of the new file.  Because the
+// partial line at the end of the old file will never be completed, it
+// is passed to fn as the last line of the old file.
+//
+// To receive the next line of text, fn must return (true, nil).  If
+// fn returns an error, it is returned by FollowLines().  FollowLines()
+// runs until fn asks it to stop, ctx is cancelled, or an error occurs.
+// If ctx is cancelled, the error returned wraps ctx.Err().
+func FollowLines(
+	ctx context.Context,
+	path string,
+	opts *FollowOptions,
+	fn func(string) (bool, error),
+) error {
+	fw := &follower{
+		path: path,
+		fn:   fn,
+		buf:  make([]byte, followBufferSize),
+	}
+	if opts != nil {
+		fw.opts = *opts
+	}
+	if fw.opts.PollInterval <= 0 {
+		fw.opts.PollInterval = defaultFollowPollInterval
+	}
+	defer fw.close()
+
+	// Open the file skipping the existing lines unless told
+	// otherwise.
+	err := fw.open(!fw.opts.FromStart)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(fw.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+
+		// Pass the new lines to the callback.
+		more, err := fw.poll()
+		if err != nil || !more {
+			return err
+		}
+
+		// Wait for the next poll.
+		select {
+		case <-ctx.Done():
+			return contextError(ctx)
+		case <-ticker.C:
+		}
+	}
+}
+
+// open opens the file if it exists.  If skip is true, the existing
+// contents of the file are skipped.
+func (fw *follower) open(skip bool) error {
+	f, err := os.Open(fw.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	fw.f = f
+	fw.offset = 0
+	fw.pending = fw.pending[:0]
+	if skip {
+		fw.offset, err = f.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// close closes the file if it is open.
+func (fw *follower) close() {
+	if fw.f != nil {
+		fw.f.Close()
+		fw.f = nil
+	}
+}
+
+// poll reads any new lines from the file and checks if the file has
+// been truncated or rotated.  It returns false if the callback asked
+// to stop.
+func (fw *follower) poll() (bool, error) {
+
+	// Wait for the file to be created.
+	if fw.f == nil {
+		err := fw.open(false)
+		if err != nil || fw.f == nil {
+			return true, err
+		}
+	}
+
+	// Read the new lines.
+	more, err := fw.read()
+	if err != nil || !more {
+		return more, err
+	}
+
+	// Check if the file was rotated.  If path does not exist, the
+	// file was probably renamed, and the new one has not been created
+	// yet so keep following the old file.
+	pathInfo, err := os.Stat(fw.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return true, nil
+		}
+		return false, err
+	}
+	fileInfo, err := fw.f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if !os.SameFile(pathInfo, fileInfo) {
+
+		// Finish reading the old file including any partial line.
+		more, err = fw.read()
+		if err != nil || !more {
+			return more, err
+		}
+		more, err = fw.flush()
+		if err != nil || !more {
+			return more, err
+		}
+
+		// Start reading the new file.
+		fw.close()
+		err = fw.open(false)
+		if err != nil || fw.f == nil {
+			return true, err
+		}
+		return fw.read()
+	}
+
+	// Check if the file was truncated.
+	if fileInfo.Size() < fw.offset {
+		more, err = fw.flush()
+		if err != nil || !more {
+			return more, err
+		}
+		_, err = fw.f.Seek(0, io.SeekStart)
+		if err != nil {
+			return false, err
+		}
+		fw.offset = 0
+		return fw.read()
+	}
+
+	return true, nil
+}
+
+// read reads until the end of the file passing each complete line to
+// the callback.
+func (fw *follower) read() (bool, error) {
+	for {
+		n, err := fw.f.Read(fw.buf)
+		fw.offset += int64(n)
+		fw.pending = append(fw.pending, fw.buf[:n]...)
+
+		// Pass each complete line to the callback.
+		more, fnErr := fw.emit(false)
+		if fnErr != nil || !more {
+			return more, fnErr
+		}
+
+		if err == io.EOF || (err == nil && n == 0) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
+
+// flush passes the partial line, if any, to the callback.
+func (fw *follower) flush() (bool, error) {
+	return fw.emit(true)
+}
+
+// emit passes each complete line in the pending data to the callback.
+// If atEOF is true, the partial line at the end is also passed.
+func (fw *follower) emit(atEOF bool) (bool, error) {
+	start := 0
+	defer func() {
+		fw.pending = fw.pending[:copy(fw.pending, fw.pending[start:])]
+	}()
+	for start < len(fw.pending) {
+		n := findEOL(fw.pending[start:], atEOF)
+		if n < 0 {
+			if !atEOF {
+				return true, nil
+			}
+			n = len(fw.pending) - start
+		}
+		line := string(fw.pending[start : start+n])
+		start += n
+		if fw.opts.StripEOL {
+			line = StripEOL(line)
+		}
+		more, err := fw.fn(line)
+		if err != nil || !more {
+			return more, err
+		}
+	}
+	return true, nil
+}
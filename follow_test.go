@@ -0,0 +1,150 @@
+package jrutil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// followTest runs FollowLines() in the background and collects the
+// lines it finds.
+type followTest struct {
+	t      *testing.T
+	path   string
+	lines  chan string
+	done   chan error
+	cancel context.CancelFunc
+}
+
+// startFollowTest starts following the file at path.
+func startFollowTest(t *testing.T, path string, fromStart bool) *followTest {
+	ctx, cancel := context.WithCancel(context.Background())
+	ft := &followTest{
+		t:      t,
+		path:   path,
+		lines:  make(chan string, 100),
+		done:   make(chan error, 1),
+		cancel: cancel,
+	}
+	opts := &FollowOptions{
+		PollInterval: 5 * time.Millisecond,
+		FromStart:    fromStart,
+	}
+	go func() {
+		ft.done <- FollowLines(ctx, path, opts,
+			func(line string) (bool, error) {
+				ft.lines <- line
+				return true, nil
+			})
+	}()
+	return ft
+}
+
+// expect waits for the expected lines.
+func (ft *followTest) expect(expected ...string) {
+	ft.t.Helper()
+	for _, e := range expected {
+		select {
+		case actual := <-ft.lines:
+			if actual != e {
+				ft.t.Fatalf("FollowLines: expected=%q  actual=%q", e, actual)
+			}
+		case <-time.After(5 * time.Second):
+			ft.t.Fatalf("FollowLines: timed out waiting for %q", e)
+		}
+	}
+}
+
+// expectNothing makes sure no lines arrive for several polls.
+func (ft *followTest) expectNothing() {
+	ft.t.Helper()
+	select {
+	case actual := <-ft.lines:
+		ft.t.Fatalf("FollowLines: unexpected line %q", actual)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// stop stops FollowLines() and checks that it returned the context
+// error.
+func (ft *followTest) stop() {
+	ft.t.Helper()
+	ft.cancel()
+	err := <-ft.done
+	if !errors.Is(err, context.Canceled) {
+		ft.t.Errorf("FollowLines: expected=%v  actual=%v", context.Canceled, err)
+	}
+}
+
+// appendFile appends the text to the file at path.
+func appendFile(t *testing.T, path string, text string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	defer f.Close()
+	_, err = f.WriteString(text)
+	if err != nil {
+		t.Fatalf("WriteString: %v", err)
+	}
+}
+
+// TestFollowLines tests following a file that grows, is truncated,
+// and is rotated.
+func TestFollowLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "old\n")
+
+	ft := startFollowTest(t, path, false)
+	defer ft.stop()
+
+	// Existing lines are skipped, and partial lines are held until
+	// their EOL arrives.
+	ft.expectNothing()
+	appendFile(t, path, "foo\n")
+	ft.expect("foo\n")
+	appendFile(t, path, "ba")
+	ft.expectNothing()
+	appendFile(t, path, "r\r")
+	ft.expectNothing()
+	appendFile(t, path, "\nbaz\r")
+	ft.expect("bar\r\n")
+	appendFile(t, path, "qux\n")
+	ft.expect("baz\r", "qux\n")
+
+	// Truncate the file.
+	err := os.WriteFile(path, []byte("new\n"), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	ft.expect("new\n")
+
+	// Rotate the file leaving a partial line at the end of the old
+	// file.
+	appendFile(t, path, "partial")
+	ft.expectNothing()
+	err = os.Rename(path, path+".1")
+	if err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	appendFile(t, path+".1", " line\n")
+	appendFile(t, path, "rotated\n")
+	ft.expect("partial line\n", "rotated\n")
+}
+
+// TestFollowLinesFromStart tests following a file from the start
+// including waiting for it to be created.
+func TestFollowLinesFromStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	ft := startFollowTest(t, path, true)
+	defer ft.stop()
+
+	ft.expectNothing()
+	appendFile(t, path, "foo\nbar\n")
+	ft.expect("foo\n", "bar\n")
+}
@@ -40,11 +40,13 @@ func ForEachLine(
 	// set so you have to handle the remaining characters in "line"
 	// before dealing with the error.
 	for {
-		var more bool
 		var err error
 		var fnErr error
 		var line string
 
+		// Keep reading unless the callback says otherwise.
+		more := true
+
 		// Read the next line of text.
 		if strict {
 			line, err = ReadLine(br)
@@ -79,3 +81,57 @@ func ForEachLine(
 	}
 
 }
+
+// ForEachLineBytes is similar to [ForEachLine()] except it uses a
+// [LineReader] to pass each line of text to fn as a slice of bytes
+// instead of as a string.  This avoids allocating memory for each
+// line and is significantly faster than either the strict or
+// non-strict modes of ForEachLine() while still working with DOS,
+// Mac, and UNIX EOL sequences.
+//
+// The slice passed to fn is only valid until fn returns.  If fn needs
+// to hold on to the line, it must make a copy.
+func ForEachLineBytes(
+	r io.Reader,
+	stripEOL bool,
+	fn func([]byte) (bool, error),
+) error {
+	lr := NewLineReader(r)
+	for {
+		var more bool
+		var fnErr error
+
+		// Read the next line of text.
+		line, err := lr.ReadLineBytes()
+
+		// Nothing left to read.
+		if len(line) == 0 {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		// Invoke the callback.
+		if stripEOL {
+			more, fnErr = fn(StripEOLBytes(line))
+		} else {
+			more, fnErr = fn(line)
+		}
+
+		// If the callback returned an error, forward it to the caller.
+		if fnErr != nil {
+			return fnErr
+		}
+
+		// Success.
+		if !more || err == io.EOF {
+			return nil
+		}
+
+		// Return an error if ReadLineBytes failed.
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package jrutil
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"testing"
@@ -435,3 +440,229 @@ func TestForEachLineNotStrict(t *testing.T) {
 		}
 	}
 }
+
+// TestForEachLineError tests that read errors are returned by
+// ForEachLine() even when they happen between lines.
+func TestForEachLineError(t *testing.T) {
+	errExpected := errors.New("read failed")
+	for _, strict := range []bool{false, true} {
+		var actual []string
+		r := io.MultiReader(
+			strings.NewReader("foo\nbar\n"),
+			&errorReader{err: errExpected})
+		err := ForEachLine(r, true, strict,
+			func(line string) (bool, error) {
+				actual = append(actual, line)
+				return true, nil
+			})
+		if !errors.Is(err, errExpected) {
+			t.Errorf("ForEachLine(strict=%v): expected=%v  actual=%v",
+				strict, errExpected, err)
+		}
+		if !slices.Equal(actual, []string{"foo", "bar"}) {
+			t.Errorf("ForEachLine(strict=%v): unexpected lines %q",
+				strict, actual)
+		}
+	}
+}
+
+// TestForEachLineBytes tests ForEachLineBytes() which should work
+// with DOS, Mac, and Unix EOLs.
+func TestForEachLineBytes(t *testing.T) {
+	data := []struct {
+		text     string
+		stripEOL bool
+		expected []string
+	}{
+		// Stripped
+		{
+			text:     "",
+			stripEOL: true,
+			expected: []string{},
+		},
+		{
+			text:     "\r",
+			stripEOL: true,
+			expected: []string{""},
+		},
+		{
+			text:     "foo\rbar\rbaz",
+			stripEOL: true,
+			expected: []string{"foo", "bar", "baz"},
+		},
+		{
+			text:     "foo\nbar\nbaz\n",
+			stripEOL: true,
+			expected: []string{"foo", "bar", "baz"},
+		},
+		{
+			text:     "foo\rbar\nbaz\r\n",
+			stripEOL: true,
+			expected: []string{"foo", "bar", "baz"},
+		},
+
+		// Unstripped
+		{
+			text:     "",
+			stripEOL: false,
+			expected: []string{},
+		},
+		{
+			text:     "\r\n",
+			stripEOL: false,
+			expected: []string{"\r\n"},
+		},
+		{
+			text:     "foo\r\nbar\r\nbaz",
+			stripEOL: false,
+			expected: []string{"foo\r\n", "bar\r\n", "baz"},
+		},
+		{
+			text:     "foo\rbar\nbaz\r\n",
+			stripEOL: false,
+			expected: []string{"foo\r", "bar\n", "baz\r\n"},
+		},
+	}
+
+	for _, d := range data {
+		actual := []string{}
+		r := strings.NewReader(d.text)
+		err := ForEachLineBytes(r, d.stripEOL,
+			func(line []byte) (bool, error) {
+				actual = append(actual, string(line))
+				return true, nil
+			})
+		if err != nil {
+			t.Errorf("ForEachLineBytes(%q): %v", d.text, err)
+			continue
+		}
+		if !slices.Equal(actual, d.expected) {
+			t.Errorf("ForEachLineBytes(%q): expected=%q  actual=%q",
+				d.text, d.expected, actual)
+		}
+	}
+}
+
+// makeBenchmarkText returns the text used by the ForEachLine
+// benchmarks which is a large number of lines of typical length.
+func makeBenchmarkText() string {
+	var b strings.Builder
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&b,
+			"%08d: The quick brown fox jumps over the lazy dog.\r\n", i)
+	}
+	return b.String()
+}
+
+// BenchmarkForEachLineStrict benchmarks ForEachLine() in strict mode.
+func BenchmarkForEachLineStrict(b *testing.B) {
+	text := makeBenchmarkText()
+	b.SetBytes(int64(len(text)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := ForEachLine(strings.NewReader(text), true, true, /* strict */
+			func(line string) (bool, error) {
+				return true, nil
+			})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkForEachLineNotStrict benchmarks ForEachLine() in
+// non-strict mode.
+func BenchmarkForEachLineNotStrict(b *testing.B) {
+	text := makeBenchmarkText()
+	b.SetBytes(int64(len(text)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := ForEachLine(strings.NewReader(text), true, false, /* strict */
+			func(line string) (bool, error) {
+				return true, nil
+			})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkForEachLineBytes benchmarks ForEachLineBytes().
+func BenchmarkForEachLineBytes(b *testing.B) {
+	text := makeBenchmarkText()
+	b.SetBytes(int64(len(text)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := ForEachLineBytes(strings.NewReader(text), true,
+			func(line []byte) (bool, error) {
+				return true, nil
+			})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// writeBenchmarkFile writes the benchmark text to a temporary file and
+// returns its path and size.
+func writeBenchmarkFile(b *testing.B) (string, int64) {
+	text := makeBenchmarkText()
+	path := filepath.Join(b.TempDir(), "benchmark.txt")
+	err := os.WriteFile(path, []byte(text), 0644)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return path, int64(len(text))
+}
+
+// benchmarkForEachLineFile benchmarks reading the benchmark file by
+// calling forEach with the opened file.
+func benchmarkForEachLineFile(b *testing.B, forEach func(f *os.File) error) {
+	path, size := writeBenchmarkFile(b)
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		err = forEach(f)
+		f.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkForEachLineFileStrict benchmarks ForEachLine() in strict
+// mode reading from a file.
+func BenchmarkForEachLineFileStrict(b *testing.B) {
+	benchmarkForEachLineFile(b, func(f *os.File) error {
+		return ForEachLine(f, true, true, /* strict */
+			func(line string) (bool, error) {
+				return true, nil
+			})
+	})
+}
+
+// BenchmarkForEachLineFileNotStrict benchmarks ForEachLine() in
+// non-strict mode reading from a file.
+func BenchmarkForEachLineFileNotStrict(b *testing.B) {
+	benchmarkForEachLineFile(b, func(f *os.File) error {
+		return ForEachLine(f, true, false, /* strict */
+			func(line string) (bool, error) {
+				return true, nil
+			})
+	})
+}
+
+// BenchmarkForEachLineMmap benchmarks ForEachLineMmap() reading from
+// a file.
+func BenchmarkForEachLineMmap(b *testing.B) {
+	benchmarkForEachLineFile(b, func(f *os.File) error {
+		return ForEachLineMmap(f, true,
+			func(line []byte) (bool, error) {
+				return true, nil
+			})
+	})
+}
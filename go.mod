@@ -1,5 +1,5 @@
 module github.com/jalitriver/jrutil
 
-go 1.22.1
+go 1.23
 
 require github.com/google/go-cmp v0.6.0
@@ -0,0 +1,318 @@
+package jrutil
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// lineIndexMagic identifies a saved LineIndex.
+const lineIndexMagic = "JRLIDX1\n"
+
+// ErrStaleIndex is returned when the file has changed since its
+// LineIndex was built.
+var ErrStaleIndex = errors.New("line index is out of date")
+
+// ErrLineNumber is returned when a line number is out of range.
+var ErrLineNumber = errors.New("line number out of range")
+
+// ErrNotLineIndex is returned, wrapped, by [LoadLineIndex()] when the
+// sidecar file is not a valid line index.
+var ErrNotLineIndex = errors.New("not a line index")
+
+// LineIndex allows random access to the lines of a large text file by
+// holding the byte offset of every Nth line.  Lines are found using
+// the same EOL rules as [ReadLine()].  See [BuildLineIndex()].
+type LineIndex struct {
+	path    string
+	every   uint64  // sampling interval
+	offsets []int64 // offsets[i] is the start of line i*every+1
+	count   uint64
+	size    int64
+	modTime time.Time
+}
+
+// BuildLineIndex reads the file at path and returns a LineIndex that
+// holds the byte offset of every Nth line where N is given by every.
+// Smaller values of every make lookups faster at the expense of
+// memory.  If every is zero, it is treated as one.
+func BuildLineIndex(path string, every uint64) (*LineIndex, error) {
+	every = max(every, 1)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	ix := &LineIndex{
+		path:    path,
+		every:   every,
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}
+
+	// Record the offset of every Nth line.
+	lr := NewLineReader(f)
+	for {
+		offset := lr.Offset()
+		line, err := lr.ReadLineBytes()
+		if len(line) > 0 {
+			if ix.count%every == 0 {
+				ix.offsets = append(ix.offsets, offset)
+			}
+			ix.count++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ix, nil
+}
+
+// Count returns the number of lines in the file.
+func (ix *LineIndex) Count() uint64 {
+	return ix.count
+}
+
+// Valid returns true if the file has the same size and modification
+// time as it did when the index was built.
+func (ix *LineIndex) Valid() (bool, error) {
+	info, err := os.Stat(ix.path)
+	if err != nil {
+		return false, err
+	}
+	return info.Size() == ix.size && info.ModTime().Equal(ix.modTime), nil
+}
+
+// Line returns the line with the given 1-based line number with its
+// EOL sequence still attached.  If the file has changed since the
+// index was built, ErrStaleIndex is returned.
+func (ix *LineIndex) Line(n uint64) (string, error) {
+	lines, err := ix.Range(n, n)
+	if err != nil {
+		return "", err
+	}
+	return lines[0], nil
+}
+
+// Range returns the lines from the 1-based line number from through
+// the line number to inclusive with their EOL sequences still
+// attached.  If the file has changed since the index was built,
+// ErrStaleIndex is returned.
+func (ix *LineIndex) Range(from, to uint64) ([]string, error) {
+
+	// Check the line numbers.
+	if from == 0 || from > to || to > ix.count {
+		return nil, fmt.Errorf("%w: %v-%v of %v", ErrLineNumber, from, to, ix.count)
+	}
+
+	// Make sure the index is still valid.
+	f, err := os.Open(ix.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() != ix.size || !info.ModTime().Equal(ix.modTime) {
+		return nil, ErrStaleIndex
+	}
+
+	// Seek to the closest indexed line at or before "from".
+	i := (from - 1) / ix.every
+	_, err = f.Seek(ix.offsets[i], io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip to "from" and read through "to".
+	lr := NewLineReader(f)
+	var result []string
+	for n := i*ix.every + 1; n <= to; n++ {
+		line, err := lr.ReadLineBytes()
+		if len(line) == 0 && err != nil {
+			if err == io.EOF {
+				return nil, ErrStaleIndex
+			}
+			return nil, err
+		}
+		if n >= from {
+			result = append(result, string(line))
+		}
+	}
+
+	return result, nil
+}
+
+// Save writes the index to the sidecar file so it can be loaded later
+// with [LoadLineIndex()] instead of being rebuilt.
+func (ix *LineIndex) Save(sidecar string) (err error) {
+	f, err := os.Create(sidecar)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	// Write the header.
+	w := bufio.NewWriter(f)
+	_, err = w.WriteString(lineIndexMagic)
+	if err != nil {
+		return err
+	}
+	header := []uint64{
+		ix.every,
+		ix.count,
+		uint64(ix.size),
+		uint64(ix.modTime.UnixNano()),
+		uint64(len(ix.offsets)),
+	}
+	err = binary.Write(w, binary.LittleEndian, header)
+	if err != nil {
+		return err
+	}
+
+	// Write the offsets as deltas to keep the file small.
+	var buf [binary.MaxVarintLen64]byte
+	prev := int64(0)
+	for _, offset := range ix.offsets {
+		n := binary.PutUvarint(buf[:], uint64(offset-prev))
+		_, err = w.Write(buf[:n])
+		if err != nil {
+			return err
+		}
+		prev = offset
+	}
+
+	return w.Flush()
+}
+
+// LoadLineIndex loads the index for the file at path from the sidecar
+// file written by [LineIndex.Save()].  If the file has changed since
+// the index was built, ErrStaleIndex is returned.  If the sidecar file
+// is not a valid index, the error wraps ErrNotLineIndex.
+func LoadLineIndex(path string, sidecar string) (*LineIndex, error) {
+	f, err := os.Open(sidecar)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(f)
+
+	// notIndex returns the error for an invalid sidecar file.
+	notIndex := func(reason string) error {
+		return fmt.Errorf("%v: %w: %v", sidecar, ErrNotLineIndex, reason)
+	}
+
+	// Read the header.
+	magic := make([]byte, len(lineIndexMagic))
+	_, err = io.ReadFull(r, magic)
+	if err != nil || string(magic) != lineIndexMagic {
+		return nil, notIndex("bad magic")
+	}
+	header := make([]uint64, 5)
+	err = binary.Read(r, binary.LittleEndian, header)
+	if err != nil {
+		return nil, notIndex("truncated header")
+	}
+	ix := &LineIndex{
+		path:    path,
+		every:   header[0],
+		count:   header[1],
+		size:    int64(header[2]),
+		modTime: time.Unix(0, int64(header[3])),
+	}
+
+	// Make sure the index is still valid before allocating anything
+	// based on the header.
+	valid, err := ix.Valid()
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, ErrStaleIndex
+	}
+
+	// Check the rest of the header before trusting it.  Every line
+	// has at least one byte, so there cannot be more lines than
+	// bytes, and every offset takes at least one byte of the sidecar
+	// file.
+	if ix.every == 0 {
+		return nil, notIndex("sampling interval is zero")
+	}
+	if ix.count > uint64(ix.size) {
+		return nil, notIndex("line count does not fit the file size")
+	}
+	expected := ix.count / ix.every
+	if ix.count%ix.every != 0 {
+		expected++
+	}
+	if header[4] != expected {
+		return nil, notIndex("wrong number of offsets")
+	}
+	remaining := info.Size() - int64(len(lineIndexMagic)) - int64(8*len(header))
+	if header[4] > uint64(remaining) {
+		return nil, notIndex("truncated offsets")
+	}
+
+	// Read the offsets which must start at zero, increase, and stay
+	// inside the file.
+	prev := int64(0)
+	ix.offsets = make([]int64, 0, header[4])
+	for i := uint64(0); i < header[4]; i++ {
+		delta, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, notIndex("truncated offsets")
+		}
+		if (i == 0) != (delta == 0) || delta >= uint64(ix.size-prev) {
+			return nil, notIndex("offsets out of order")
+		}
+		prev += int64(delta)
+		ix.offsets = append(ix.offsets, prev)
+	}
+
+	return ix, nil
+}
+
+// OpenLineIndex loads the index for the file at path from the sidecar
+// file if it exists and is up to date.  Otherwise, it builds a new
+// index, as described for [BuildLineIndex()], and saves it to the
+// sidecar file.
+func OpenLineIndex(path string, sidecar string, every uint64) (*LineIndex, error) {
+	ix, err := LoadLineIndex(path, sidecar)
+	if err == nil {
+		return ix, nil
+	}
+	ix, err = BuildLineIndex(path, every)
+	if err != nil {
+		return nil, err
+	}
+	err = ix.Save(sidecar)
+	if err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
@@ -0,0 +1,245 @@
+package jrutil
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeLineIndexFile writes a file with count lines using a mix of
+// EOL sequences and returns its path and lines.
+func writeLineIndexFile(t *testing.T, count int) (string, []string) {
+	eols := []string{"\n", "\r\n", "\r"}
+	var lines []string
+	for i := 0; i < count; i++ {
+		lines = append(lines, fmt.Sprintf("line %v%v", i+1, eols[i%len(eols)]))
+	}
+	path := filepath.Join(t.TempDir(), "lines.txt")
+	err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path, lines
+}
+
+func TestLineIndex(t *testing.T) {
+	path, lines := writeLineIndexFile(t, 100)
+
+	for _, every := range []uint64{0, 1, 7, 100, 1000} {
+		ix, err := BuildLineIndex(path, every)
+		if err != nil {
+			t.Fatalf("BuildLineIndex: %v", err)
+		}
+		if ix.Count() != uint64(len(lines)) {
+			t.Errorf("Count(every=%v): expected=%v  actual=%v",
+				every, len(lines), ix.Count())
+		}
+		for n := uint64(1); n <= ix.Count(); n++ {
+			line, err := ix.Line(n)
+			if err != nil {
+				t.Errorf("Line(%v): %v", n, err)
+				continue
+			}
+			if line != lines[n-1] {
+				t.Errorf("Line(%v, every=%v): expected=%q  actual=%q",
+					n, every, lines[n-1], line)
+			}
+		}
+		actual, err := ix.Range(20, 45)
+		if err != nil {
+			t.Errorf("Range: %v", err)
+		}
+		if !slices.Equal(actual, lines[19:45]) {
+			t.Errorf("Range(every=%v): expected=%q  actual=%q",
+				every, lines[19:45], actual)
+		}
+	}
+}
+
+// TestLineIndexErrors tests bad line numbers and stale indexes.
+func TestLineIndexErrors(t *testing.T) {
+	path, _ := writeLineIndexFile(t, 10)
+	ix, err := BuildLineIndex(path, 3)
+	if err != nil {
+		t.Fatalf("BuildLineIndex: %v", err)
+	}
+	for _, n := range []uint64{0, 11} {
+		_, err = ix.Line(n)
+		if !errors.Is(err, ErrLineNumber) {
+			t.Errorf("Line(%v): expected=%v  actual=%v", n, ErrLineNumber, err)
+		}
+	}
+
+	// Change the file.
+	appendFile(t, path, "more\n")
+	_, err = ix.Line(1)
+	if !errors.Is(err, ErrStaleIndex) {
+		t.Errorf("Line: expected=%v  actual=%v", ErrStaleIndex, err)
+	}
+}
+
+// TestLineIndexSidecar tests saving and loading the index.
+func TestLineIndexSidecar(t *testing.T) {
+	path, lines := writeLineIndexFile(t, 50)
+	sidecar := path + ".idx"
+
+	// Build and save the index.
+	ix, err := OpenLineIndex(path, sidecar, 4)
+	if err != nil {
+		t.Fatalf("OpenLineIndex: %v", err)
+	}
+
+	// Load the index.
+	loaded, err := LoadLineIndex(path, sidecar)
+	if err != nil {
+		t.Fatalf("LoadLineIndex: %v", err)
+	}
+	if loaded.Count() != ix.Count() || !slices.Equal(loaded.offsets, ix.offsets) {
+		t.Errorf("LoadLineIndex: loaded index does not match saved index")
+	}
+	line, err := loaded.Line(37)
+	if err != nil || line != lines[36] {
+		t.Errorf("Line: expected=%q  actual=%q (%v)", lines[36], line, err)
+	}
+
+	// Loading fails after the file changes, and OpenLineIndex()
+	// rebuilds the index.
+	appendFile(t, path, "line 51\n")
+	future := time.Now().Add(time.Hour)
+	err = os.Chtimes(path, future, future)
+	if err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+	_, err = LoadLineIndex(path, sidecar)
+	if !errors.Is(err, ErrStaleIndex) {
+		t.Errorf("LoadLineIndex: expected=%v  actual=%v", ErrStaleIndex, err)
+	}
+	ix, err = OpenLineIndex(path, sidecar, 4)
+	if err != nil {
+		t.Fatalf("OpenLineIndex: %v", err)
+	}
+	if ix.Count() != 51 {
+		t.Errorf("Count: expected=51  actual=%v", ix.Count())
+	}
+}
+
+// TestLoadLineIndexInvalid tests that corrupt sidecar files are
+// rejected instead of causing panics.
+func TestLoadLineIndexInvalid(t *testing.T) {
+	path, _ := writeLineIndexFile(t, 50)
+	sidecar := path + ".idx"
+	ix, err := BuildLineIndex(path, 4)
+	if err != nil {
+		t.Fatalf("BuildLineIndex: %v", err)
+	}
+	err = ix.Save(sidecar)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	valid, err := os.ReadFile(sidecar)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+
+	// setHeader returns a copy of the valid index with the header
+	// fields at the given indexes replaced.
+	setHeader := func(fields map[int]uint64) []byte {
+		raw := slices.Clone(valid)
+		for i, v := range fields {
+			binary.LittleEndian.PutUint64(raw[len(lineIndexMagic)+8*i:], v)
+		}
+		return raw
+	}
+
+	// Each function corrupts a copy of a valid index.
+	data := []struct {
+		name     string
+		corrupt  func(bad *LineIndex) []byte
+		expected error
+	}{
+		{"zero interval", func(bad *LineIndex) []byte {
+			bad.every = 0
+			return nil
+		}, ErrNotLineIndex},
+		{"too many lines", func(bad *LineIndex) []byte {
+			bad.count = uint64(bad.size) + 1
+			return nil
+		}, ErrNotLineIndex},
+		{"too few offsets", func(bad *LineIndex) []byte {
+			bad.offsets = bad.offsets[:len(bad.offsets)-1]
+			return nil
+		}, ErrNotLineIndex},
+		{"decreasing offsets", func(bad *LineIndex) []byte {
+			bad.offsets[2], bad.offsets[3] = bad.offsets[3], bad.offsets[2]
+			return nil
+		}, ErrNotLineIndex},
+		{"nonzero first offset", func(bad *LineIndex) []byte {
+			bad.offsets[0] = 1
+			return nil
+		}, ErrNotLineIndex},
+		{"offset past the end", func(bad *LineIndex) []byte {
+			bad.offsets[len(bad.offsets)-1] = bad.size
+			return nil
+		}, ErrNotLineIndex},
+		{"huge offset count", func(bad *LineIndex) []byte {
+			raw := slices.Clone(valid)
+			for i := len(lineIndexMagic) + 32; i < len(lineIndexMagic)+40; i++ {
+				raw[i] = 0xff
+			}
+			return raw
+		}, ErrNotLineIndex},
+		{"truncated", func(bad *LineIndex) []byte {
+			return valid[:len(valid)-3]
+		}, ErrNotLineIndex},
+		{"offset count overflows", func(bad *LineIndex) []byte {
+			bad.every = math.MaxUint64
+			bad.count = 2
+			bad.offsets = nil
+			return nil
+		}, ErrNotLineIndex},
+		{"more offsets than the sidecar holds", func(bad *LineIndex) []byte {
+			return setHeader(map[int]uint64{
+				0: 1,
+				1: uint64(bad.size),
+				4: uint64(bad.size),
+			})
+		}, ErrNotLineIndex},
+		{"huge file", func(bad *LineIndex) []byte {
+			return setHeader(map[int]uint64{
+				0: 1,
+				1: 1 << 60,
+				2: 1 << 60,
+				4: 1 << 60,
+			})
+		}, ErrStaleIndex},
+	}
+
+	for _, d := range data {
+		bad := *ix
+		bad.offsets = slices.Clone(ix.offsets)
+		raw := d.corrupt(&bad)
+		if raw == nil {
+			err = bad.Save(sidecar)
+			if err != nil {
+				t.Fatalf("Save(%v): %v", d.name, err)
+			}
+		} else {
+			err = os.WriteFile(sidecar, raw, 0644)
+			if err != nil {
+				t.Fatalf("WriteFile(%v): %v", d.name, err)
+			}
+		}
+		_, err = LoadLineIndex(path, sidecar)
+		if !errors.Is(err, d.expected) {
+			t.Errorf("LoadLineIndex(%v): expected=%v  actual=%v",
+				d.name, d.expected, err)
+		}
+	}
+}
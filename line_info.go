@@ -0,0 +1,105 @@
+package jrutil
+
+import (
+	"fmt"
+	"io"
+)
+
+// LineInfo describes where a line of text was found in the input.
+type LineInfo struct {
+
+	// Number is the 1-based line number.
+	Number uint64
+
+	// Offset is the byte offset in the input of the start of the
+	// line.
+	Offset int64
+
+	// EOL is the EOL sequence that terminated the line.
+	EOL EOL
+}
+
+// LineError is the error returned by [ForEachLineInfo()] when the
+// callback fails.  It records where in the input the failure
+// happened so it can be recovered with errors.As() and used to
+// generate "file:line: message" diagnostics.
+type LineError struct {
+	LineInfo
+	Err error
+}
+
+// Error returns the error message prefixed by the line number.
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %v: %v", e.Number, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// ForEachLineInfo is similar to [ForEachLine()] except fn also
+// receives a [LineInfo] that describes where the line was found.
+// Lines are read with a [LineReader] so DOS, Mac, and UNIX EOL
+// sequences are all recognized.
+//
+// If fn returns an error, it is wrapped in a *[LineError] that
+// carries the same LineInfo that was passed to fn.  Errors from r are
+// returned as-is.
+func ForEachLineInfo(
+	r io.Reader,
+	stripEOL bool,
+	fn func(string, LineInfo) (bool, error),
+) error {
+	var number uint64
+
+	lr := NewLineReader(r)
+	for {
+
+		// Read the next line of text.
+		offset := lr.Offset()
+		line, err := lr.ReadLineBytes()
+
+		// Nothing left to read.
+		if len(line) == 0 {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		// Describe the line.
+		stripped := StripEOLBytes(line)
+		number++
+		info := LineInfo{
+			Number: number,
+			Offset: offset,
+			EOL:    EOLOf(string(line[len(stripped):])),
+		}
+
+		// Invoke the callback.
+		var more bool
+		var fnErr error
+		if stripEOL {
+			more, fnErr = fn(string(stripped), info)
+		} else {
+			more, fnErr = fn(string(line), info)
+		}
+
+		// If the callback returned an error, forward it to the
+		// caller along with where it happened.
+		if fnErr != nil {
+			return &LineError{LineInfo: info, Err: fnErr}
+		}
+
+		// Success.
+		if !more || err == io.EOF {
+			return nil
+		}
+
+		// Return an error if ReadLineBytes failed.
+		if err != nil {
+			return err
+		}
+	}
+}
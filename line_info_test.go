@@ -0,0 +1,68 @@
+package jrutil
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestForEachLineInfo(t *testing.T) {
+	text := "foo\rbar\nbaz\r\nqux"
+	expectedLines := []string{"foo", "bar", "baz", "qux"}
+	expectedInfos := []LineInfo{
+		{Number: 1, Offset: 0, EOL: EOLMac},
+		{Number: 2, Offset: 4, EOL: EOLUnix},
+		{Number: 3, Offset: 8, EOL: EOLDOS},
+		{Number: 4, Offset: 13, EOL: EOLNone},
+	}
+
+	var actualLines []string
+	var actualInfos []LineInfo
+	err := ForEachLineInfo(strings.NewReader(text), true,
+		func(line string, info LineInfo) (bool, error) {
+			actualLines = append(actualLines, line)
+			actualInfos = append(actualInfos, info)
+			return true, nil
+		})
+	if err != nil {
+		t.Errorf("ForEachLineInfo: %v", err)
+	}
+	if !slices.Equal(actualLines, expectedLines) {
+		t.Errorf("ForEachLineInfo: expected=%q  actual=%q",
+			expectedLines, actualLines)
+	}
+	if !slices.Equal(actualInfos, expectedInfos) {
+		t.Errorf("ForEachLineInfo: expected=%v  actual=%v",
+			expectedInfos, actualInfos)
+	}
+}
+
+// TestForEachLineInfoError tests that an error returned by the
+// callback is wrapped in a LineError that records where it happened.
+func TestForEachLineInfoError(t *testing.T) {
+	errExpected := errors.New("bad line")
+	err := ForEachLineInfo(strings.NewReader("foo\nbar\nbaz\n"), false,
+		func(line string, info LineInfo) (bool, error) {
+			if line == "bar\n" {
+				return false, errExpected
+			}
+			return true, nil
+		})
+
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) {
+		t.Fatalf("ForEachLineInfo: expected *LineError  actual=%v", err)
+	}
+	if !errors.Is(err, errExpected) {
+		t.Errorf("ForEachLineInfo: expected=%v  actual=%v", errExpected, err)
+	}
+	expected := LineInfo{Number: 2, Offset: 4, EOL: EOLUnix}
+	if lineErr.LineInfo != expected {
+		t.Errorf("ForEachLineInfo: expected=%v  actual=%v",
+			expected, lineErr.LineInfo)
+	}
+	if lineErr.Error() != "line 2: bad line" {
+		t.Errorf("ForEachLineInfo: unexpected message %q", lineErr.Error())
+	}
+}
@@ -0,0 +1,180 @@
+package jrutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrLineTooLong is wrapped by every *LineTooLongError so the two can
+// be tested for with errors.Is().
+var ErrLineTooLong = errors.New("line too long")
+
+// LineTooLongError is the error returned for a line that exceeds the
+// maximum line length when using the LongLineError policy.
+type LineTooLongError struct {
+
+	// Offset is the byte offset in the input of the start of the
+	// line.
+	Offset int64
+
+	// Limit is the maximum line length.
+	Limit int
+}
+
+// Error returns the error message.
+func (e *LineTooLongError) Error() string {
+	return fmt.Sprintf("line at offset %v is longer than %v bytes",
+		e.Offset, e.Limit)
+}
+
+// Unwrap returns ErrLineTooLong.
+func (e *LineTooLongError) Unwrap() error {
+	return ErrLineTooLong
+}
+
+// LongLinePolicy selects what a LineReader does with a line that is
+// longer than the maximum line length.
+type LongLinePolicy int
+
+const (
+	// LongLineError skips the line and returns a *LineTooLongError
+	// in its place.  Reading can continue with the next line.
+	LongLineError LongLinePolicy = iota
+
+	// LongLineTruncate returns the first part of the line up to the
+	// maximum line length followed by the EOL sequence of the line.
+	// The rest of the line is skipped.
+	LongLineTruncate
+
+	// LongLineSplit returns the line in chunks that are no longer
+	// than the maximum line length.  Only the last chunk has the EOL
+	// sequence attached.  [LineReader.Continued()] reports whether a
+	// chunk is continued by the next one.
+	LongLineSplit
+)
+
+// SetMaxLineLength limits the length of each line, not counting its
+// EOL sequence, to maxLength bytes.  Lines that are longer are handled
+// according to policy.  This also limits the amount of memory used by
+// the LineReader which is important when reading untrusted input that
+// might not have any EOLs.  A maxLength of zero removes the limit.
+func (lr *LineReader) SetMaxLineLength(maxLength int, policy LongLinePolicy) {
+	lr.maxLength = max(maxLength, 0)
+	lr.policy = policy
+}
+
+// Continued returns true if the line returned by the last call to
+// ReadLineBytes() was split because it was too long and is continued
+// by the line returned by the next call.
+func (lr *LineReader) Continued() bool {
+	return lr.continued
+}
+
+// tooLong returns true if a line of the given length, not counting its
+// EOL sequence, is longer than the maximum line length.
+func (lr *LineReader) tooLong(length int) bool {
+	return lr.maxLength > 0 && length > lr.maxLength
+}
+
+// readLongLine handles the line at the start of the unread data which
+// is known to be too long according to the LongLinePolicy.
+func (lr *LineReader) readLongLine() ([]byte, error) {
+	offset := lr.offset
+	switch lr.policy {
+	case LongLineSplit:
+		chunk := lr.buf[lr.start : lr.start+lr.maxLength]
+		lr.advance(lr.maxLength)
+		lr.continued = true
+		return chunk, nil
+	case LongLineTruncate:
+		lr.truncated = append(lr.truncated[:0],
+			lr.buf[lr.start:lr.start+lr.maxLength]...)
+		lr.advance(lr.maxLength)
+		lr.truncated = append(lr.truncated, lr.skipLine()...)
+		return lr.truncated, nil
+	default:
+		lr.skipLine()
+		return nil, &LineTooLongError{Offset: offset, Limit: lr.maxLength}
+	}
+}
+
+// skipLine consumes the rest of the current line and returns its EOL
+// sequence.  The returned slice refers to the internal buffer.
+func (lr *LineReader) skipLine() []byte {
+	for {
+		window := lr.buf[lr.start:lr.end]
+		n := findEOL(window, lr.err != nil)
+		if n >= 0 {
+			eol := window[len(StripEOLBytes(window[:n])):n]
+			lr.advance(n)
+			return eol
+		}
+		if lr.err != nil {
+			lr.advance(len(window))
+			return nil
+		}
+
+		// Keep a trailing '\r' because it might be the start of
+		// "\r\n".
+		n = len(window)
+		if n > 0 && window[n-1] == '\r' {
+			n--
+		}
+		lr.advance(n)
+		lr.fill()
+	}
+}
+
+// ForEachLineLimit is similar to [ForEachLineBytes()] except the
+// length of each line, not counting its EOL sequence, is limited to
+// maxLength bytes, and lines that are longer are handled according to
+// policy.  See [LineReader.SetMaxLineLength()] for details.  When
+// using LongLineSplit, the second argument passed to fn is true if
+// the line is continued by the next call to fn.  Otherwise, it is
+// always false.  When using LongLineError, the *LineTooLongError is
+// returned to the caller.
+func ForEachLineLimit(
+	r io.Reader,
+	stripEOL bool,
+	maxLength int,
+	policy LongLinePolicy,
+	fn func(string, bool) (bool, error),
+) error {
+	lr := NewLineReader(r)
+	lr.SetMaxLineLength(maxLength, policy)
+	for {
+
+		// Read the next line of text.
+		line, err := lr.ReadLineBytes()
+
+		// Nothing left to read.
+		if len(line) == 0 {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		// Invoke the callback.
+		if stripEOL {
+			line = StripEOLBytes(line)
+		}
+		more, fnErr := fn(string(line), lr.Continued())
+
+		// If the callback returned an error, forward it to the caller.
+		if fnErr != nil {
+			return fnErr
+		}
+
+		// Success.
+		if !more || err == io.EOF {
+			return nil
+		}
+
+		// Return an error if ReadLineBytes failed.
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package jrutil
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// lineLimitResult is a line read by ForEachLineLimit() along with its
+// continuation flag.
+type lineLimitResult struct {
+	line      string
+	continued bool
+}
+
+func TestForEachLineLimit(t *testing.T) {
+	data := []struct {
+		text     string
+		policy   LongLinePolicy
+		expected []lineLimitResult
+	}{
+		{
+			text:   "foo\nbarbaz\r\nqux\r",
+			policy: LongLineTruncate,
+			expected: []lineLimitResult{
+				{"foo\n", false},
+				{"barb\r\n", false},
+				{"qux\r", false},
+			},
+		},
+		{
+			text:   "abcd\r\nabcde\rabcdefghij",
+			policy: LongLineTruncate,
+			expected: []lineLimitResult{
+				{"abcd\r\n", false},
+				{"abcd\r", false},
+				{"abcd", false},
+			},
+		},
+		{
+			text:   "foo\nbarbaz\r\nqux\r",
+			policy: LongLineSplit,
+			expected: []lineLimitResult{
+				{"foo\n", false},
+				{"barb", true},
+				{"az\r\n", false},
+				{"qux\r", false},
+			},
+		},
+		{
+			text:   "abcdefghij\nk",
+			policy: LongLineSplit,
+			expected: []lineLimitResult{
+				{"abcd", true},
+				{"efgh", true},
+				{"ij\n", false},
+				{"k", false},
+			},
+		},
+		{
+			text:   "abcdefgh",
+			policy: LongLineSplit,
+			expected: []lineLimitResult{
+				{"abcd", true},
+				{"efgh", false},
+			},
+		},
+	}
+
+	for _, oneByte := range []bool{false, true} {
+		for _, d := range data {
+			var r io.Reader = strings.NewReader(d.text)
+			if oneByte {
+				r = iotest.OneByteReader(r)
+			}
+			var actual []lineLimitResult
+			err := ForEachLineLimit(r, false, 4, d.policy,
+				func(line string, continued bool) (bool, error) {
+					actual = append(actual, lineLimitResult{line, continued})
+					return true, nil
+				})
+			if err != nil {
+				t.Errorf("ForEachLineLimit(%q): %v", d.text, err)
+				continue
+			}
+			if !slices.Equal(actual, d.expected) {
+				t.Errorf("ForEachLineLimit(%q, %v): expected=%+v  actual=%+v",
+					d.text, d.policy, d.expected, actual)
+			}
+		}
+	}
+}
+
+// TestLineReaderLongLineError tests that a line that is too long is
+// reported with its offset and that reading can continue.
+func TestLineReaderLongLineError(t *testing.T) {
+	lr := NewLineReader(strings.NewReader("foo\nbarbaz\r\nqux"))
+	lr.SetMaxLineLength(4, LongLineError)
+
+	line, err := lr.ReadLine()
+	if line != "foo\n" || err != nil {
+		t.Errorf("ReadLine: expected=%q  actual=%q (%v)", "foo\n", line, err)
+	}
+
+	_, err = lr.ReadLine()
+	var lineErr *LineTooLongError
+	if !errors.As(err, &lineErr) || !errors.Is(err, ErrLineTooLong) {
+		t.Fatalf("ReadLine: expected *LineTooLongError  actual=%v", err)
+	}
+	if lineErr.Offset != 4 || lineErr.Limit != 4 {
+		t.Errorf("ReadLine: unexpected error %+v", lineErr)
+	}
+
+	line, err = lr.ReadLine()
+	if line != "qux" || err != io.EOF {
+		t.Errorf("ReadLine: expected=%q  actual=%q (%v)", "qux", line, err)
+	}
+}
+
+// TestLineReaderLongLineMemory tests that the buffer does not grow
+// without bound when the input has no EOLs.
+func TestLineReaderLongLineMemory(t *testing.T) {
+	text := strings.Repeat("x", 10*lineReaderBufferSize) + "\n"
+	lr := NewLineReader(strings.NewReader(text))
+	lr.SetMaxLineLength(100, LongLineTruncate)
+	line, err := lr.ReadLine()
+	if err != nil {
+		t.Errorf("ReadLine: %v", err)
+	}
+	if line != strings.Repeat("x", 100)+"\n" {
+		t.Errorf("ReadLine: unexpected line of length %v", len(line))
+	}
+	if len(lr.buf) != lineReaderBufferSize {
+		t.Errorf("ReadLine: buffer grew to %v bytes", len(lr.buf))
+	}
+}
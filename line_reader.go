@@ -0,0 +1,187 @@
+package jrutil
+
+import (
+	"bytes"
+	"io"
+)
+
+// lineReaderBufferSize is the initial size of the buffer used by
+// LineReader.  The buffer grows as needed to hold longer lines.
+const lineReaderBufferSize = 64 * 1024
+
+// lineReaderMaxEmptyReads is the number of times LineReader will
+// retry a read that returns no data and no error before giving up
+// with io.ErrNoProgress.
+const lineReaderMaxEmptyReads = 100
+
+// LineReader is a fast, allocation-free line reader that recognizes
+// the same EOL sequences as [ReadLine()], namely "\r", "\n", and
+// "\r\n".  Instead of inspecting the input one byte at a time, it
+// scans its internal buffer in bulk for EOLs and returns slices into
+// that buffer which avoids allocating a new string for every line.
+type LineReader struct {
+	r         io.Reader
+	buf       []byte
+	start     int   // start of the unread data in buf
+	end       int   // end of the valid data in buf
+	offset    int64 // offset in the input of the unread data
+	err       error // sticky error returned by r
+	maxLength int   // maximum line length or zero for no limit
+	policy    LongLinePolicy
+	continued bool   // true if the last line was split
+	truncated []byte // holds the last truncated line
+}
+
+// NewLineReader returns a new LineReader that reads from r.  Because
+// LineReader does its own buffering, there is no benefit in wrapping
+// r in a bufio.Reader.
+func NewLineReader(r io.Reader) *LineReader {
+	return &LineReader{
+		r:   r,
+		buf: make([]byte, lineReaderBufferSize),
+	}
+}
+
+// ReadLineBytes returns the next line of text with the EOL sequence
+// still attached.  Call [jrutil.StripEOLBytes()] to remove the EOL
+// sequence.  The returned slice refers to the internal buffer of the
+// LineReader and is only valid until the next call to ReadLineBytes.
+// Callers that need to hold on to the line must copy it.
+//
+// Like [ReadLine()], if the last line of text does not have a
+// trailing EOL sequence, both the line and io.EOF are returned
+// together.  If the underlying reader fails, whatever was read of the
+// current line is returned along with the error.
+//
+// If a maximum line length has been set by calling
+// [LineReader.SetMaxLineLength()], lines that are too long are
+// handled according to the LongLinePolicy.
+func (lr *LineReader) ReadLineBytes() ([]byte, error) {
+
+	// Number of bytes at the start of the unread data that are known
+	// not to contain an EOL which avoids rescanning them after each
+	// fill().
+	scanned := 0
+
+	lr.continued = false
+	for {
+
+		// Look for the end of the line in the buffered data.
+		window := lr.buf[lr.start:lr.end]
+		n := findEOL(window[scanned:], lr.err != nil)
+		if n >= 0 {
+			n += scanned
+			if lr.tooLong(len(StripEOLBytes(window[:n]))) {
+				return lr.readLongLine()
+			}
+			lr.advance(n)
+			return window[:n], nil
+		}
+
+		// Check if the line is already too long.  A trailing '\r'
+		// might be part of the EOL so it does not count.
+		length := len(window)
+		if length > 0 && window[length-1] == '\r' {
+			length--
+		}
+		if lr.tooLong(length) {
+			return lr.readLongLine()
+		}
+
+		// If the underlying reader is done, return whatever is left.
+		if lr.err != nil {
+			lr.advance(len(window))
+			if len(window) == 0 {
+				return nil, lr.err
+			}
+			return window, lr.err
+		}
+
+		// Remember how much has been scanned so far.  A trailing
+		// '\r' must be scanned again because it might be the start
+		// of "\r\n".
+		scanned = len(window)
+		if scanned > 0 && window[scanned-1] == '\r' {
+			scanned--
+		}
+
+		// Read more data.
+		lr.fill()
+	}
+}
+
+// advance consumes n bytes of the buffered data.
+func (lr *LineReader) advance(n int) {
+	lr.start += n
+	lr.offset += int64(n)
+}
+
+// Offset returns the byte offset in the input of the start of the
+// next line to be read.
+func (lr *LineReader) Offset() int64 {
+	return lr.offset
+}
+
+// ReadLine is the same as [LineReader.ReadLineBytes()] except it
+// returns a newly allocated string.
+func (lr *LineReader) ReadLine() (string, error) {
+	line, err := lr.ReadLineBytes()
+	return string(line), err
+}
+
+// fill reads more data into the buffer sliding the unread data to
+// the start of the buffer and growing the buffer as needed.
+func (lr *LineReader) fill() {
+
+	// Slide the unread data to the start of the buffer.
+	if lr.start > 0 {
+		copy(lr.buf, lr.buf[lr.start:lr.end])
+		lr.end -= lr.start
+		lr.start = 0
+	}
+
+	// Grow the buffer if it is full.
+	if lr.end == len(lr.buf) {
+		buf := make([]byte, 2*len(lr.buf))
+		copy(buf, lr.buf[:lr.end])
+		lr.buf = buf
+	}
+
+	// Read more data.
+	for i := 0; i < lineReaderMaxEmptyReads; i++ {
+		n, err := lr.r.Read(lr.buf[lr.end:])
+		lr.end += n
+		if err != nil {
+			lr.err = err
+			return
+		}
+		if n > 0 {
+			return
+		}
+	}
+	lr.err = io.ErrNoProgress
+}
+
+// findEOL returns the length of the first line in buf including its
+// EOL sequence, or -1 if buf does not hold a complete line.  Because
+// the next byte might be '\n', a '\r' at the very end of buf only
+// ends the line if atEOF is true.
+func findEOL(buf []byte, atEOF bool) int {
+	i := bytes.IndexAny(buf, "\r\n")
+	if i < 0 {
+		return -1
+	}
+	if buf[i] == '\n' {
+		return i + 1 // EOL = "\n"
+	}
+	if i+1 < len(buf) {
+		if buf[i+1] == '\n' {
+			return i + 2 // EOL = "\r\n"
+		}
+		return i + 1 // EOL = "\r"
+	}
+	if atEOF {
+		return i + 1 // EOL = "\r"
+	}
+	return -1
+}
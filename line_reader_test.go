@@ -0,0 +1,134 @@
+package jrutil
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLineReader(t *testing.T) {
+	data := []struct {
+		text     string
+		expected []string
+	}{
+		{
+			text:     "",
+			expected: []string{},
+		},
+		{
+			text:     "\r",
+			expected: []string{"\r"},
+		},
+		{
+			text:     "\n",
+			expected: []string{"\n"},
+		},
+		{
+			text:     "\r\n",
+			expected: []string{"\r\n"},
+		},
+		{
+			text:     "\n\r",
+			expected: []string{"\n", "\r"},
+		},
+		{
+			text:     "\r\r\n",
+			expected: []string{"\r", "\r\n"},
+		},
+		{
+			text:     "foo",
+			expected: []string{"foo"},
+		},
+		{
+			text:     "foo\r",
+			expected: []string{"foo\r"},
+		},
+		{
+			text:     "foo\n",
+			expected: []string{"foo\n"},
+		},
+		{
+			text:     "foo\r\n",
+			expected: []string{"foo\r\n"},
+		},
+		{
+			text:     "foo\rbar",
+			expected: []string{"foo\r", "bar"},
+		},
+		{
+			text:     "foo\nbar",
+			expected: []string{"foo\n", "bar"},
+		},
+		{
+			text:     "foo\r\nbar",
+			expected: []string{"foo\r\n", "bar"},
+		},
+		{
+			text:     "foo\rbar\nbaz\r\n",
+			expected: []string{"foo\r", "bar\n", "baz\r\n"},
+		},
+		{
+			text:     "foo\r\rbar\n\nbaz\r\n\r\n",
+			expected: []string{"foo\r", "\r", "bar\n", "\n", "baz\r\n", "\r\n"},
+		},
+	}
+
+	// Read the text normally and one byte at a time so EOLs, and
+	// especially "\r\n", are split across reads.
+	for _, oneByte := range []bool{false, true} {
+		for _, d := range data {
+			var r io.Reader = strings.NewReader(d.text)
+			if oneByte {
+				r = iotest.OneByteReader(r)
+			}
+			actual := []string{}
+			lr := NewLineReader(r)
+			for {
+				line, err := lr.ReadLine()
+				if line != "" {
+					actual = append(actual, line)
+				}
+				if err != nil {
+					if err != io.EOF {
+						t.Errorf("ReadLine: %v", err)
+					}
+					break
+				}
+			}
+			if !slices.Equal(actual, d.expected) {
+				t.Errorf("LineReader(%q, oneByte=%v): expected=%q  actual=%q",
+					d.text, oneByte, d.expected, actual)
+			}
+		}
+	}
+}
+
+// TestLineReaderLongLines tests lines that are longer than the
+// initial size of the internal buffer.
+func TestLineReaderLongLines(t *testing.T) {
+	expected := []string{
+		strings.Repeat("a", lineReaderBufferSize-1) + "\r",
+		strings.Repeat("b", lineReaderBufferSize) + "\r\n",
+		strings.Repeat("c", 3*lineReaderBufferSize) + "\n",
+		"d",
+	}
+	lr := NewLineReader(strings.NewReader(strings.Join(expected, "")))
+	actual := []string{}
+	for {
+		line, err := lr.ReadLine()
+		if line != "" {
+			actual = append(actual, line)
+		}
+		if err != nil {
+			if err != io.EOF {
+				t.Errorf("ReadLine: %v", err)
+			}
+			break
+		}
+	}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("LineReader: long lines were not read correctly")
+	}
+}
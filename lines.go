@@ -0,0 +1,68 @@
+package jrutil
+
+import (
+	"bufio"
+	"io"
+	"iter"
+)
+
+// Lines returns an iterator over the lines of text in the io.Reader r
+// that is suitable for use with a for-range loop:
+//
+//	for line, err := range jrutil.Lines(r, true, false) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(line)
+//	}
+//
+// The strict and stripEOL parameters have the same meaning as they do
+// for [ForEachLine()].  Each line is yielded with a nil error.  If
+// reading fails for any reason other than io.EOF, the iterator yields
+// a single empty line with the error and then stops.  Breaking out of
+// the loop stops reading immediately.
+func Lines(r io.Reader, stripEOL bool, strict bool) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+
+		// If necessary, wrap file in bufio.Reader to get buffered input.
+		br, ok := r.(*bufio.Reader)
+		if !ok {
+			br = bufio.NewReader(r)
+		}
+
+		// See ForEachLine() for why both "line" and "err" must be
+		// handled on each pass.
+		for {
+			var err error
+			var line string
+
+			// Read the next line of text.
+			if strict {
+				line, err = ReadLine(br)
+			} else {
+				line, err = br.ReadString('\n')
+			}
+
+			// Yield the line if at least part of it was read.
+			if line != "" {
+				if stripEOL {
+					line = StripEOL(line)
+				}
+				if !yield(line, nil) {
+					return
+				}
+			}
+
+			// Success.
+			if err == io.EOF {
+				return
+			}
+
+			// Yield the error if reading failed.
+			if err != nil {
+				yield("", err)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package jrutil
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestLines tests Lines() in both strict and non-strict modes.
+func TestLines(t *testing.T) {
+	data := []struct {
+		text     string
+		stripEOL bool
+		strict   bool
+		expected []string
+	}{
+		{
+			text:     "",
+			stripEOL: true,
+			strict:   true,
+			expected: []string{},
+		},
+		{
+			text:     "foo\rbar\nbaz\r\n",
+			stripEOL: true,
+			strict:   true,
+			expected: []string{"foo", "bar", "baz"},
+		},
+		{
+			text:     "foo\rbar\nbaz\r\n",
+			stripEOL: false,
+			strict:   true,
+			expected: []string{"foo\r", "bar\n", "baz\r\n"},
+		},
+		{
+			text:     "foo\nbar\r\nbaz",
+			stripEOL: true,
+			strict:   false,
+			expected: []string{"foo", "bar", "baz"},
+		},
+		{
+			text:     "foo\nbar\r\nbaz",
+			stripEOL: false,
+			strict:   false,
+			expected: []string{"foo\n", "bar\r\n", "baz"},
+		},
+	}
+
+	for _, d := range data {
+		actual := []string{}
+		for line, err := range Lines(strings.NewReader(d.text), d.stripEOL, d.strict) {
+			if err != nil {
+				t.Errorf("Lines(%q): %v", d.text, err)
+				break
+			}
+			actual = append(actual, line)
+		}
+		if !slices.Equal(actual, d.expected) {
+			t.Errorf("Lines(%q): expected=%q  actual=%q",
+				d.text, d.expected, actual)
+		}
+	}
+}
+
+// TestLinesBreak tests that breaking out of the loop stops the
+// iteration.
+func TestLinesBreak(t *testing.T) {
+	var actual []string
+	r := strings.NewReader("foo\nbar\nbaz\n")
+	for line := range Lines(r, true, true) {
+		actual = append(actual, line)
+		if line == "bar" {
+			break
+		}
+	}
+	expected := []string{"foo", "bar"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("Lines: expected=%q  actual=%q", expected, actual)
+	}
+}
+
+// TestLinesError tests that a read error is yielded to the caller.
+func TestLinesError(t *testing.T) {
+	errExpected := errors.New("read failed")
+	r := io.MultiReader(
+		strings.NewReader("foo\nbar"),
+		&errorReader{err: errExpected})
+
+	var lines []string
+	var errActual error
+	for line, err := range Lines(r, true, false) {
+		if err != nil {
+			errActual = err
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if !errors.Is(errActual, errExpected) {
+		t.Errorf("Lines: expected=%v  actual=%v", errExpected, errActual)
+	}
+	if !slices.Equal(lines, []string{"foo", "bar"}) {
+		t.Errorf("Lines: unexpected lines %q", lines)
+	}
+}
+
+// errorReader is an io.Reader that always fails with err.
+type errorReader struct {
+	err error
+}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,116 @@
+package jrutil
+
+import (
+	"io"
+	"math"
+	"os"
+)
+
+// ForEachLineMmap is similar to [ForEachLineBytes()] except, if f is
+// a regular file and the platform supports it (currently only Linux),
+// the file is memory-mapped and scanned for EOLs directly which avoids
+// copying the data into a buffer.  The slices passed to fn point into
+// the mapping and are only valid until fn returns.  For pipes,
+// terminals, empty files, and other files that cannot be mapped, this
+// function falls back to ForEachLineBytes().
+//
+// Lines are read from the current offset of f to the end of the file.
+// When done, the offset of f is set to just after the last line
+// passed to fn unless f cannot seek, like a pipe, in which case the
+// offset is wherever reading stopped.  The file must not be truncated
+// while it is mapped because accessing the missing pages crashes the
+// program.
+func ForEachLineMmap(
+	f *os.File,
+	stripEOL bool,
+	fn func([]byte) (bool, error),
+) error {
+
+	// Find out how much of the file is left to read.
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() || info.Size() == 0 || info.Size() > math.MaxInt {
+		return forEachLineFallback(f, stripEOL, fn)
+	}
+	pos, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return forEachLineFallback(f, stripEOL, fn)
+	}
+
+	// Map the file.
+	data, unmap, err := mmapFile(f, int(info.Size()))
+	if err != nil {
+		return forEachLineFallback(f, stripEOL, fn)
+	}
+	defer unmap()
+
+	// Iterate over each line of text.
+	start := min(pos, int64(len(data)))
+	end, fnErr := forEachLineInBytes(data[start:], stripEOL, fn)
+	_, err = f.Seek(start+int64(end), io.SeekStart)
+	if fnErr != nil {
+		return fnErr
+	}
+	return err
+}
+
+// forEachLineInBytes invokes fn for each line of text in data and
+// returns the number of bytes consumed.
+func forEachLineInBytes(
+	data []byte,
+	stripEOL bool,
+	fn func([]byte) (bool, error),
+) (int, error) {
+	pos := 0
+	for pos < len(data) {
+
+		// Find the end of the line.  Because all of the data is
+		// present, a missing EOL means this is the last line.
+		n := findEOL(data[pos:], true)
+		if n < 0 {
+			n = len(data) - pos
+		}
+		line := data[pos : pos+n : pos+n]
+		pos += n
+
+		// Invoke the callback.
+		if stripEOL {
+			line = StripEOLBytes(line)
+		}
+		more, err := fn(line)
+		if err != nil || !more {
+			return pos, err
+		}
+	}
+	return pos, nil
+}
+
+// forEachLineFallback is used by ForEachLineMmap() for files that
+// cannot be mapped.  It reads the lines with ForEachLineBytes() and
+// then, if f can seek, moves the offset back to just after the last
+// line passed to fn because the LineReader reads ahead.
+func forEachLineFallback(
+	f *os.File,
+	stripEOL bool,
+	fn func([]byte) (bool, error),
+) error {
+	pos, seekErr := f.Seek(0, io.SeekCurrent)
+	var consumed int64
+	err := ForEachLineBytes(f, false, func(line []byte) (bool, error) {
+		consumed += int64(len(line))
+		if stripEOL {
+			line = StripEOLBytes(line)
+		}
+		return fn(line)
+	})
+	if seekErr != nil {
+		return err
+	}
+	_, seekErr = f.Seek(pos+consumed, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	return seekErr
+}
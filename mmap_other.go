@@ -0,0 +1,14 @@
+//go:build !linux
+
+package jrutil
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapFile always fails because memory-mapping is only supported on
+// Linux.
+func mmapFile(f *os.File, size int) ([]byte, func() error, error) {
+	return nil, nil, errors.ErrUnsupported
+}
@@ -0,0 +1,118 @@
+package jrutil
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// collectMmapLines returns the lines passed to fn by forEach stopping
+// after limit lines if limit is positive.
+func collectMmapLines(
+	t *testing.T,
+	forEach func(*os.File, bool, func([]byte) (bool, error)) error,
+	f *os.File,
+	stripEOL bool,
+	limit int,
+) []string {
+	var lines []string
+	err := forEach(f, stripEOL, func(line []byte) (bool, error) {
+		lines = append(lines, string(line))
+		return limit <= 0 || len(lines) < limit, nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachLineMmap: %v", err)
+	}
+	return lines
+}
+
+// openTestFile writes text to a temporary file and opens it.
+func openTestFile(t *testing.T, text string) *os.File {
+	path := filepath.Join(t.TempDir(), "input.txt")
+	err := os.WriteFile(path, []byte(text), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestForEachLineMmap(t *testing.T) {
+	data := []struct {
+		text     string
+		stripEOL bool
+		expected []string
+	}{
+		{"", false, nil},
+		{"a", false, []string{"a"}},
+		{"a\nb\r\nc\rd", false, []string{"a\n", "b\r\n", "c\r", "d"}},
+		{"a\nb\r\nc\rd\r", true, []string{"a", "b", "c", "d"}},
+		{"\n\r\n\r", true, []string{"", "", ""}},
+	}
+	for _, d := range data {
+		f := openTestFile(t, d.text)
+		actual := collectMmapLines(t, ForEachLineMmap, f, d.stripEOL, 0)
+		if !slices.Equal(actual, d.expected) {
+			t.Errorf("ForEachLineMmap(%q): expected=%q  actual=%q",
+				d.text, d.expected, actual)
+		}
+	}
+}
+
+// TestForEachLineMmapOffset tests that the offset of the file is left
+// just after the last line for both the mapped and fallback paths.
+func TestForEachLineMmapOffset(t *testing.T) {
+	data := []struct {
+		name    string
+		forEach func(*os.File, bool, func([]byte) (bool, error)) error
+	}{
+		{"ForEachLineMmap", ForEachLineMmap},
+		{"forEachLineFallback", forEachLineFallback},
+	}
+	for _, d := range data {
+		f := openTestFile(t, "a\nb\nc\nd\n")
+
+		// Start after the first line.
+		_, err := f.Seek(2, io.SeekStart)
+		if err != nil {
+			t.Fatalf("Seek: %v", err)
+		}
+
+		// Stop after two lines which should leave the offset at "d".
+		actual := collectMmapLines(t, d.forEach, f, true, 2)
+		expected := []string{"b", "c"}
+		if !slices.Equal(actual, expected) {
+			t.Errorf("%v: expected=%q  actual=%q", d.name, expected, actual)
+		}
+		rest, err := io.ReadAll(f)
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+		if string(rest) != "d\n" {
+			t.Errorf("%v: expected_rest=%q  actual_rest=%q", d.name, "d\n", rest)
+		}
+	}
+}
+
+func TestForEachLineMmapPipe(t *testing.T) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	defer pr.Close()
+	go func() {
+		_, _ = pw.WriteString("a\r\nb\rc")
+		pw.Close()
+	}()
+	actual := collectMmapLines(t, ForEachLineMmap, pr, true, 0)
+	expected := []string{"a", "b", "c"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("ForEachLineMmap: expected=%q  actual=%q", expected, actual)
+	}
+}
@@ -0,0 +1,176 @@
+package jrutil
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// mapLinesResult holds the result of mapping a single line.
+type mapLinesResult[T any] struct {
+	value T
+	err   error
+}
+
+// mapLinesJob is a single line to be mapped by one of the workers
+// along with the channel on which the result should be delivered.
+type mapLinesJob[T any] struct {
+	line   string
+	result chan mapLinesResult[T]
+}
+
+// MapLines reads each line of text from r using [ForEachLine()],
+// transforms the lines in parallel by calling mapFn from the given
+// number of worker goroutines, and then passes the results to sink in
+// the same order the lines were read.  If workers is less than one,
+// runtime.GOMAXPROCS(0) workers are used.  The stripEOL and strict
+// parameters have the same meaning as they do for ForEachLine().
+//
+// Memory is bounded because no more than 2*workers lines can be read
+// ahead of the line currently being passed to sink.
+//
+// To receive the next result, sink must return (true, nil).  If
+// mapFn or sink returns an error, or if reading r fails, MapLines
+// stops reading, waits for the workers to finish, and returns the
+// first such error in input order.  Lines before the failing line are
+// still passed to sink just as they would be by ForEachLine().  If ctx
+// is cancelled, ctx.Err() is returned.  Note that a blocked read of r
+// is not interrupted by cancelling ctx.  See [NewContextReader()] if
+// that is needed.
+func MapLines[T any](
+	ctx context.Context,
+	r io.Reader,
+	stripEOL bool,
+	strict bool,
+	workers int,
+	mapFn func(string) (T, error),
+	sink func(T) (bool, error),
+) error {
+
+	// Use one worker per CPU by default.
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	// The context that is cancelled to shut everything down.
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The workers receive lines to map on "jobs".  The result for
+	// each line is delivered on its own channel which is queued on
+	// "pending" in input order.  The capacity of "pending" limits how
+	// far the reader can get ahead of the sink.
+	jobs := make(chan mapLinesJob[T])
+	pending := make(chan chan mapLinesResult[T], 2*workers)
+
+	// Start the workers.
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				value, err := mapFn(job.line)
+				job.result <- mapLinesResult[T]{value: value, err: err}
+			}
+		}()
+	}
+
+	// Start the reader.  Read errors are queued on "pending" just
+	// like the results so they are reported in input order.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(pending)
+		defer close(jobs)
+
+		err := ForEachLine(r, stripEOL, strict,
+			func(line string) (bool, error) {
+				result := make(chan mapLinesResult[T], 1)
+				select {
+				case pending <- result:
+				case <-ctx.Done():
+					return false, nil
+				}
+				select {
+				case jobs <- mapLinesJob[T]{line: line, result: result}:
+				case <-ctx.Done():
+					return false, nil
+				}
+				return true, nil
+			})
+
+		if err != nil {
+			result := make(chan mapLinesResult[T], 1)
+			result <- mapLinesResult[T]{err: err}
+			select {
+			case pending <- result:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	// Deliver the results to the sink in input order.
+	var err error
+	for result := range pending {
+		var res mapLinesResult[T]
+		select {
+		case res = <-result:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if res.err != nil {
+			err = res.err
+			break
+		}
+		more, sinkErr := sink(res.value)
+		if sinkErr != nil {
+			err = sinkErr
+			break
+		}
+		if !more {
+			break
+		}
+	}
+
+	// Shut down the reader and the workers.
+	cancel()
+	for range pending {
+	}
+	wg.Wait()
+
+	if err != nil {
+		return err
+	}
+	return parent.Err()
+}
+
+// ParallelForEachLine is similar to [ForEachLine()] except fn is
+// invoked for each line of text from the given number of worker
+// goroutines.  Because the calls to fn happen concurrently, they are
+// not in any particular order, and fn cannot stop the iteration
+// except by returning an error.  See [MapLines()] for details about
+// the remaining parameters and error handling.
+func ParallelForEachLine(
+	ctx context.Context,
+	r io.Reader,
+	stripEOL bool,
+	strict bool,
+	workers int,
+	fn func(string) error,
+) error {
+	return MapLines(ctx, r, stripEOL, strict, workers,
+		func(line string) (struct{}, error) {
+			return struct{}{}, fn(line)
+		},
+		func(struct{}) (bool, error) {
+			return true, nil
+		})
+}
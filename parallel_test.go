@@ -0,0 +1,139 @@
+package jrutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// makeParallelText returns count lines of text numbered from zero.
+func makeParallelText(count int) (string, []string) {
+	var lines []string
+	for i := 0; i < count; i++ {
+		lines = append(lines, fmt.Sprintf("line %v", i))
+	}
+	return strings.Join(lines, "\n") + "\n", lines
+}
+
+// TestMapLines tests that the results are delivered in input order
+// even though the workers finish out of order.
+func TestMapLines(t *testing.T) {
+	text, lines := makeParallelText(200)
+	for _, strict := range []bool{false, true} {
+		var actual []string
+		err := MapLines(context.Background(), strings.NewReader(text),
+			true, strict, 8,
+			func(line string) (string, error) {
+				time.Sleep(time.Duration(len(line)%3) * time.Millisecond)
+				return strings.ToUpper(line), nil
+			},
+			func(line string) (bool, error) {
+				actual = append(actual, line)
+				return true, nil
+			})
+		if err != nil {
+			t.Errorf("MapLines: %v", err)
+		}
+		expected := Map(lines, strings.ToUpper)
+		if !slices.Equal(actual, expected) {
+			t.Errorf("MapLines(strict=%v): expected=%q  actual=%q",
+				strict, expected, actual)
+		}
+	}
+}
+
+// TestMapLinesError tests that the first error in input order is
+// returned and that the lines before it are still passed to the sink.
+func TestMapLinesError(t *testing.T) {
+	text, lines := makeParallelText(100)
+	errExpected := errors.New("map failed")
+	var actual []string
+	err := MapLines(context.Background(), strings.NewReader(text),
+		true, false, 4,
+		func(line string) (string, error) {
+			if line == "line 50" || line == "line 70" {
+				return "", fmt.Errorf("%v: %w", line, errExpected)
+			}
+			return line, nil
+		},
+		func(line string) (bool, error) {
+			actual = append(actual, line)
+			return true, nil
+		})
+	if !errors.Is(err, errExpected) || !strings.HasPrefix(err.Error(), "line 50") {
+		t.Errorf("MapLines: expected=%v  actual=%v", errExpected, err)
+	}
+	if !slices.Equal(actual, lines[:50]) {
+		t.Errorf("MapLines: expected=%q  actual=%q", lines[:50], actual)
+	}
+}
+
+// TestMapLinesStop tests that the sink can stop the iteration early.
+func TestMapLinesStop(t *testing.T) {
+	text, lines := makeParallelText(100)
+	var actual []string
+	err := MapLines(context.Background(), strings.NewReader(text),
+		true, false, 4,
+		func(line string) (string, error) {
+			return line, nil
+		},
+		func(line string) (bool, error) {
+			actual = append(actual, line)
+			return len(actual) < 10, nil
+		})
+	if err != nil {
+		t.Errorf("MapLines: %v", err)
+	}
+	if !slices.Equal(actual, lines[:10]) {
+		t.Errorf("MapLines: expected=%q  actual=%q", lines[:10], actual)
+	}
+}
+
+// TestMapLinesCancel tests that cancelling the context stops the
+// iteration and returns ctx.Err().
+func TestMapLinesCancel(t *testing.T) {
+	text, _ := makeParallelText(1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	count := 0
+	err := MapLines(ctx, strings.NewReader(text), true, false, 4,
+		func(line string) (string, error) {
+			return line, nil
+		},
+		func(line string) (bool, error) {
+			count++
+			if count == 10 {
+				cancel()
+			}
+			return true, nil
+		})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("MapLines: expected=%v  actual=%v", context.Canceled, err)
+	}
+	if count >= 1000 {
+		t.Errorf("MapLines: cancellation did not stop the iteration")
+	}
+}
+
+func TestParallelForEachLine(t *testing.T) {
+	text, lines := makeParallelText(500)
+	var count atomic.Int64
+	err := ParallelForEachLine(context.Background(), strings.NewReader(text),
+		true, true, 0,
+		func(line string) error {
+			count.Add(1)
+			return nil
+		})
+	if err != nil {
+		t.Errorf("ParallelForEachLine: %v", err)
+	}
+	if count.Load() != int64(len(lines)) {
+		t.Errorf("ParallelForEachLine: expected=%v  actual=%v",
+			len(lines), count.Load())
+	}
+}
@@ -0,0 +1,221 @@
+package jrutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// progressCheckLines is how many lines ForEachLineProgress()
+// processes between checks of the clock which keeps the cost of
+// progress reporting out of the hot loop.
+const progressCheckLines = 256
+
+// Progress describes how far [ForEachLineProgress()] has gotten.
+type Progress struct {
+
+	// Bytes is the number of bytes consumed so far.
+	Bytes int64
+
+	// Lines is the number of lines processed so far.
+	Lines uint64
+
+	// Elapsed is the time since processing started.
+	Elapsed time.Duration
+
+	// Size is the total number of bytes that will be consumed or -1
+	// if it is not known.
+	Size int64
+
+	// Percent is the percentage of Size consumed or -1 if Size is not
+	// known.
+	Percent float64
+
+	// ETA is the estimated time remaining or -1 if it is not known.
+	ETA time.Duration
+
+	// Done is true for the final report.
+	Done bool
+}
+
+// ProgressOptions are the options for [ForEachLineProgress()].
+type ProgressOptions struct {
+
+	// Interval is the minimum time between reports.  The default is
+	// one second.
+	Interval time.Duration
+
+	// Observer is called with each report.  It is called from the
+	// same goroutine as the callback passed to ForEachLineProgress()
+	// so it does not need to be safe for concurrent use.
+	Observer func(Progress)
+}
+
+// lener is implemented by readers such as *strings.Reader and
+// *bytes.Reader that know how many bytes are left to read.
+type lener interface {
+	Len() int
+}
+
+// sizer is implemented by readers that know their total size.
+type sizer interface {
+	Size() int64
+}
+
+// readerSize returns the number of bytes left to read in r or -1 if
+// it is not known.
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case *os.File:
+		info, err := v.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		pos, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return max(info.Size()-pos, 0)
+	case lener:
+		return int64(v.Len())
+	case sizer:
+		return v.Size()
+	}
+	return -1
+}
+
+// ForEachLineProgress is the same as [ForEachLine()] except it also
+// reports its progress by calling opts.Observer at most once per
+// opts.Interval and once more when done.  If r is a regular *os.File
+// or has a Len() or Size() method, the reports include the percentage
+// done and the estimated time remaining.  To keep the cost of
+// reporting low, the clock is only checked every few hundred lines, so
+// reports can be late if fn is slow.  A ready-made observer that draws
+// a progress bar is returned by [NewProgressBar()].  If opts is nil
+// or opts.Observer is nil, no progress is reported.
+func ForEachLineProgress(
+	r io.Reader,
+	stripEOL bool,
+	strict bool,
+	opts *ProgressOptions,
+	fn func(string) (bool, error),
+) error {
+	if opts == nil || opts.Observer == nil {
+		return ForEachLine(r, stripEOL, strict, fn)
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	p := Progress{Size: readerSize(r)}
+	start := time.Now()
+	last := start
+
+	// report sends the current progress to the observer.
+	report := func(now time.Time, done bool) {
+		p.Elapsed = now.Sub(start)
+		p.Done = done
+		p.Percent = -1
+		p.ETA = -1
+		if p.Size > 0 {
+			p.Percent = min(100*float64(p.Bytes)/float64(p.Size), 100)
+			if p.Bytes > 0 {
+				remaining := float64(max(p.Size-p.Bytes, 0))
+				p.ETA = time.Duration(float64(p.Elapsed) * remaining / float64(p.Bytes))
+			}
+		} else if p.Size == 0 {
+			p.Percent = 100
+			p.ETA = 0
+		}
+		opts.Observer(p)
+	}
+
+	err := ForEachLine(r, false, strict, func(line string) (bool, error) {
+		p.Bytes += int64(len(line))
+		p.Lines++
+		if p.Lines%progressCheckLines == 0 {
+			if now := time.Now(); now.Sub(last) >= interval {
+				last = now
+				report(now, false)
+			}
+		}
+		if stripEOL {
+			line = StripEOL(line)
+		}
+		return fn(line)
+	})
+	report(time.Now(), true)
+
+	return err
+}
+
+// NewProgressBar returns an observer for [ForEachLineProgress()] that
+// draws a one-line progress bar on w, typically os.Stderr, by
+// redrawing the line after a carriage return.  The bar itself is
+// width characters wide.  If the size of the input is not known, only
+// the counts and elapsed time are shown.  A newline is written after
+// the final report.
+func NewProgressBar(w io.Writer, width int) func(Progress) {
+	width = max(width, 1)
+	lastLength := 0
+	return func(p Progress) {
+		var sb strings.Builder
+		if p.Percent >= 0 {
+			filled := int(p.Percent / 100 * float64(width))
+			filled = min(filled, width)
+			sb.WriteString("[")
+			sb.WriteString(strings.Repeat("=", filled))
+			if filled < width {
+				sb.WriteString(">")
+				sb.WriteString(strings.Repeat(" ", width-filled-1))
+			}
+			fmt.Fprintf(&sb, "] %5.1f%% ", p.Percent)
+		}
+		fmt.Fprintf(&sb, "%v %v lines %v",
+			formatProgressBytes(p.Bytes), p.Lines, formatProgressDuration(p.Elapsed))
+		if p.ETA >= 0 && !p.Done {
+			fmt.Fprintf(&sb, " ETA %v", formatProgressDuration(p.ETA))
+		}
+
+		// Pad with spaces to erase what is left of the last line.
+		line := sb.String()
+		length := len(line)
+		if length < lastLength {
+			line += strings.Repeat(" ", lastLength-length)
+		}
+		lastLength = length
+
+		line = "\r" + line
+		if p.Done {
+			line += "\n"
+		}
+		_, _ = io.WriteString(w, line)
+	}
+}
+
+// formatProgressBytes formats n using binary units.
+func formatProgressBytes(n int64) string {
+	const units = "KMGTPE"
+	if n < 1024 {
+		return fmt.Sprintf("%v B", n)
+	}
+	value := float64(n) / 1024
+	i := 0
+	for value >= 1024 && i < len(units)-1 {
+		value /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, units[i])
+}
+
+// formatProgressDuration formats d as h:mm:ss or m:ss.
+func formatProgressDuration(d time.Duration) string {
+	s := int64(d.Round(time.Second) / time.Second)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
@@ -0,0 +1,155 @@
+package jrutil
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestForEachLineProgress(t *testing.T) {
+	text, lines := makeSampleText(10000)
+
+	// Collect the reports.
+	var reports []Progress
+	opts := &ProgressOptions{
+		Interval: time.Nanosecond,
+		Observer: func(p Progress) { reports = append(reports, p) },
+	}
+
+	// Known size.
+	var actual []string
+	err := ForEachLineProgress(strings.NewReader(text), true, false, opts,
+		func(line string) (bool, error) {
+			actual = append(actual, line)
+			return true, nil
+		})
+	if err != nil {
+		t.Fatalf("ForEachLineProgress: %v", err)
+	}
+	if !slices.Equal(actual, lines) {
+		t.Errorf("ForEachLineProgress: expected_lines=%v  actual_lines=%v",
+			len(lines), len(actual))
+	}
+	if len(reports) < 2 {
+		t.Fatalf("ForEachLineProgress: expected_reports>=2  actual_reports=%v",
+			len(reports))
+	}
+	for i, p := range reports[:len(reports)-1] {
+		if p.Done || p.Size != int64(len(text)) || p.Percent < 0 || p.Percent > 100 || p.ETA < 0 {
+			t.Errorf("ForEachLineProgress: report %v is out of range: %+v", i, p)
+		}
+		if i > 0 && p.Bytes <= reports[i-1].Bytes {
+			t.Errorf("ForEachLineProgress: report %v bytes did not increase: %+v", i, p)
+		}
+	}
+	expected := Progress{
+		Bytes:   int64(len(text)),
+		Lines:   10000,
+		Size:    int64(len(text)),
+		Percent: 100,
+		ETA:     0,
+		Done:    true,
+	}
+	final := reports[len(reports)-1]
+	final.Elapsed = 0
+	if final != expected {
+		t.Errorf("ForEachLineProgress: expected=%+v  actual=%+v", expected, final)
+	}
+
+	// Unknown size.
+	reports = nil
+	err = ForEachLineProgress(struct{ io.Reader }{strings.NewReader(text)}, false, true, opts,
+		func(line string) (bool, error) { return true, nil })
+	if err != nil {
+		t.Fatalf("ForEachLineProgress: %v", err)
+	}
+	expected = Progress{
+		Bytes:   int64(len(text)),
+		Lines:   10000,
+		Size:    -1,
+		Percent: -1,
+		ETA:     -1,
+		Done:    true,
+	}
+	final = reports[len(reports)-1]
+	final.Elapsed = 0
+	if final != expected {
+		t.Errorf("ForEachLineProgress: expected=%+v  actual=%+v", expected, final)
+	}
+
+	// Partially consumed reader.
+	r := strings.NewReader("skip\na\nb\n")
+	_, err = r.Seek(5, io.SeekStart)
+	if err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	reports = nil
+	err = ForEachLineProgress(r, false, false, opts,
+		func(line string) (bool, error) { return true, nil })
+	if err != nil {
+		t.Fatalf("ForEachLineProgress: %v", err)
+	}
+	final = reports[len(reports)-1]
+	if final.Size != 4 || final.Percent != 100 {
+		t.Errorf("ForEachLineProgress: expected_size=4  actual_size=%v  actual_percent=%v",
+			final.Size, final.Percent)
+	}
+}
+
+func TestForEachLineProgressFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.txt")
+	err := os.WriteFile(path, []byte("skip\na\nb\n"), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+
+	// The size only counts what is left to read.
+	_, err = f.Seek(5, io.SeekStart)
+	if err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	var final Progress
+	opts := &ProgressOptions{Observer: func(p Progress) { final = p }}
+	err = ForEachLineProgress(f, false, false, opts,
+		func(line string) (bool, error) { return true, nil })
+	if err != nil {
+		t.Fatalf("ForEachLineProgress: %v", err)
+	}
+	expected := Progress{Bytes: 4, Lines: 2, Size: 4, Percent: 100, Done: true}
+	final.Elapsed = 0
+	if final != expected {
+		t.Errorf("ForEachLineProgress: expected=%+v  actual=%+v", expected, final)
+	}
+}
+
+func TestNewProgressBar(t *testing.T) {
+	var sb strings.Builder
+	bar := NewProgressBar(&sb, 10)
+	bar(Progress{Bytes: 2048, Lines: 10, Elapsed: 5 * time.Second,
+		Size: 4096, Percent: 50, ETA: 5 * time.Second})
+	bar(Progress{Bytes: 4096, Lines: 20, Elapsed: 10 * time.Second,
+		Size: 4096, Percent: 100, ETA: 0, Done: true})
+	expected := "\r[=====>    ]  50.0% 2.0 KiB 10 lines 0:05 ETA 0:05" +
+		"\r[==========] 100.0% 4.0 KiB 20 lines 0:10         \n"
+	if sb.String() != expected {
+		t.Errorf("NewProgressBar: expected=%q  actual=%q", expected, sb.String())
+	}
+
+	sb.Reset()
+	bar = NewProgressBar(&sb, 10)
+	bar(Progress{Bytes: 10, Lines: 1, Elapsed: time.Hour + time.Second,
+		Size: -1, Percent: -1, ETA: -1, Done: true})
+	expected = "\r10 B 1 lines 1:00:01\n"
+	if sb.String() != expected {
+		t.Errorf("NewProgressBar: expected=%q  actual=%q", expected, sb.String())
+	}
+}
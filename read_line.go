@@ -8,9 +8,10 @@ import (
 // ReadLine returns the next line of text with EOL sequence still
 // attached.  Call [jrutil.StripEOL()] to remove the EOL sequence.
 // Valid end-of-line sequences are "\r", "\n", or "\r\n".  This method
-// assumes the input stream is UTF-8 encoded.  Performance should be
-// improved if r is passed in as a bufio.Reader.  Also see
-// [ForEachLine()].
+// assumes the input stream is UTF-8 encoded.  Use [NewUTF8Reader()]
+// to read UTF-16 encoded input or to check for invalid UTF-8.
+// Performance should be improved if r is passed in as a bufio.Reader.
+// Also see [ForEachLine()].
 func ReadLine(r *bufio.Reader) (string, error) {
 
 	//
@@ -0,0 +1,203 @@
+package jrutil
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Delimiter specifies how records are separated for [ReadRecord()] and
+// [ForEachRecord()].  Use [ByteDelimiter()], [StringDelimiter()], or
+// [ParagraphDelimiter()] to create one.  The zero value is the same as
+// ByteDelimiter('\n').
+type Delimiter struct {
+	seq       []byte
+	paragraph bool
+}
+
+// sequence returns the bytes that terminate a record which is "\n" for
+// the zero value.
+func (d Delimiter) sequence() []byte {
+	if len(d.seq) == 0 {
+		return []byte{'\n'}
+	}
+	return d.seq
+}
+
+// ByteDelimiter returns a Delimiter for records that are terminated by
+// a single byte, for example, NUL as written by "find -print0" or the
+// ASCII record separator, 0x1E.
+func ByteDelimiter(b byte) Delimiter {
+	return Delimiter{seq: []byte{b}}
+}
+
+// StringDelimiter returns a Delimiter for records that are terminated
+// by a sequence of bytes such as "\n\n".  It panics if s is empty.
+func StringDelimiter(s string) Delimiter {
+	if s == "" {
+		panic("jrutil: empty record delimiter")
+	}
+	return Delimiter{seq: []byte(s)}
+}
+
+// ParagraphDelimiter returns a Delimiter for paragraph mode where
+// records are separated by one or more blank lines like Perl's
+// paragraph mode.  A blank line is "\n" or "\r\n".  Blank lines
+// before the first paragraph are skipped, and the blank lines after
+// each paragraph are part of its delimiter.
+func ParagraphDelimiter() Delimiter {
+	return Delimiter{paragraph: true}
+}
+
+// ReadRecord returns the next record with its delimiter still
+// attached.  Call [StripDelimiter()] to remove the delimiter.  Like
+// [ReadLine()], if the last record does not have a trailing
+// delimiter, both the record and io.EOF are returned together.
+func ReadRecord(r *bufio.Reader, d Delimiter) (string, error) {
+
+	// Handle paragraph mode.
+	if d.paragraph {
+		return readParagraph(r)
+	}
+
+	// Handle a single byte delimiter.
+	seq := d.sequence()
+	last := seq[len(seq)-1]
+	if len(seq) == 1 {
+		return r.ReadString(last)
+	}
+
+	// Handle a multi-byte delimiter by reading up to its last byte
+	// until the record ends with the delimiter.
+	var result []byte
+	for {
+		chunk, err := r.ReadSlice(last)
+		result = append(result, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return string(result), err
+		}
+		if bytes.HasSuffix(result, seq) {
+			return string(result), nil
+		}
+	}
+}
+
+// isBlankLine returns true if line only has an EOL sequence.
+func isBlankLine(line string) bool {
+	return line == "\n" || line == "\r\n"
+}
+
+// blankLineAhead returns true if the next line is blank without
+// consuming it.
+func blankLineAhead(r *bufio.Reader) bool {
+	ahead, _ := r.Peek(2)
+	if len(ahead) >= 1 && ahead[0] == '\n' {
+		return true
+	}
+	return len(ahead) == 2 && ahead[0] == '\r' && ahead[1] == '\n'
+}
+
+// readParagraph returns the next paragraph followed by the blank
+// lines that end it.
+func readParagraph(r *bufio.Reader) (string, error) {
+	var result strings.Builder
+
+	// Skip the blank lines before the paragraph.
+	for blankLineAhead(r) {
+		_, err := r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Read the lines of the paragraph up to and including the first
+	// blank line.
+	for {
+		line, err := r.ReadString('\n')
+		result.WriteString(line)
+		if err != nil {
+			return result.String(), err
+		}
+		if isBlankLine(line) {
+			break
+		}
+	}
+
+	// Read the rest of the blank lines.
+	for blankLineAhead(r) {
+		line, err := r.ReadString('\n')
+		result.WriteString(line)
+		if err != nil {
+			return result.String(), err
+		}
+	}
+
+	return result.String(), nil
+}
+
+// StripDelimiter returns the record with its delimiter removed.  In
+// paragraph mode, all trailing EOL sequences are removed.
+func StripDelimiter(record string, d Delimiter) string {
+	if d.paragraph {
+		return strings.TrimRight(record, "\r\n")
+	}
+	return strings.TrimSuffix(record, string(d.sequence()))
+}
+
+// ForEachRecord is similar to [ForEachLine()] except it invokes fn for
+// each record in r where records are separated as specified by d
+// instead of by EOL sequences.  If stripDelimiter is true, the
+// delimiter is stripped from each record before fn is called.  See
+// ForEachLine() for how fn controls the iteration.
+func ForEachRecord(
+	r io.Reader,
+	d Delimiter,
+	stripDelimiter bool,
+	fn func(string) (bool, error),
+) error {
+
+	// If necessary, wrap file in bufio.Reader to get buffered input.
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	for {
+
+		// Read the next record.
+		record, err := ReadRecord(br, d)
+
+		// Nothing left to read.
+		if record == "" {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		// Invoke the callback.
+		if stripDelimiter {
+			record = StripDelimiter(record, d)
+		}
+		more, fnErr := fn(record)
+
+		// If the callback returned an error, forward it to the caller.
+		if fnErr != nil {
+			return fnErr
+		}
+
+		// Success.
+		if !more || err == io.EOF {
+			return nil
+		}
+
+		// Return an error if ReadRecord failed.
+		if err != nil {
+			return err
+		}
+	}
+}
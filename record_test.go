@@ -0,0 +1,110 @@
+package jrutil
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestForEachRecord(t *testing.T) {
+	data := []struct {
+		text      string
+		delimiter Delimiter
+		strip     bool
+		expected  []string
+	}{
+		{
+			text:      "",
+			delimiter: ByteDelimiter(0),
+			strip:     true,
+			expected:  []string{},
+		},
+		{
+			text:      "foo\x00bar baz\x00qux",
+			delimiter: ByteDelimiter(0),
+			strip:     true,
+			expected:  []string{"foo", "bar baz", "qux"},
+		},
+		{
+			text:      "foo\x1ebar\n\x1e",
+			delimiter: ByteDelimiter(0x1e),
+			strip:     false,
+			expected:  []string{"foo\x1e", "bar\n\x1e"},
+		},
+		{
+			text:      "foo\nbar\n\nbaz\n\n\nqux",
+			delimiter: StringDelimiter("\n\n"),
+			strip:     true,
+			expected:  []string{"foo\nbar", "baz", "\nqux"},
+		},
+		{
+			text:      "a<>b<c>d<><>",
+			delimiter: StringDelimiter("<>"),
+			strip:     false,
+			expected:  []string{"a<>", "b<c>d<>", "<>"},
+		},
+		{
+			text:      "\n\nfoo\nbar\n\n\nbaz\r\n\r\nqux\n",
+			delimiter: ParagraphDelimiter(),
+			strip:     false,
+			expected:  []string{"foo\nbar\n\n\n", "baz\r\n\r\n", "qux\n"},
+		},
+		{
+			text:      "\n\nfoo\nbar\n\n\nbaz\r\n\r\nqux\n",
+			delimiter: ParagraphDelimiter(),
+			strip:     true,
+			expected:  []string{"foo\nbar", "baz", "qux"},
+		},
+		{
+			text:      "foo\nbar\n",
+			delimiter: Delimiter{},
+			strip:     true,
+			expected:  []string{"foo", "bar"},
+		},
+		{
+			text:      "\n\n\n",
+			delimiter: ParagraphDelimiter(),
+			strip:     true,
+			expected:  []string{},
+		},
+	}
+
+	for _, d := range data {
+		actual := []string{}
+		err := ForEachRecord(strings.NewReader(d.text), d.delimiter, d.strip,
+			func(record string) (bool, error) {
+				actual = append(actual, record)
+				return true, nil
+			})
+		if err != nil {
+			t.Errorf("ForEachRecord(%q): %v", d.text, err)
+			continue
+		}
+		if !slices.Equal(actual, d.expected) {
+			t.Errorf("ForEachRecord(%q): expected=%q  actual=%q",
+				d.text, d.expected, actual)
+		}
+	}
+}
+
+// TestReadRecordLong tests a multi-byte delimiter with records that
+// are longer than the bufio.Reader buffer.
+func TestReadRecordLong(t *testing.T) {
+	expected := []string{
+		strings.Repeat("a", 10000) + "||",
+		strings.Repeat("b|c", 5000) + "||",
+	}
+	var actual []string
+	err := ForEachRecord(strings.NewReader(strings.Join(expected, "")),
+		StringDelimiter("||"), false,
+		func(record string) (bool, error) {
+			actual = append(actual, record)
+			return true, nil
+		})
+	if err != nil {
+		t.Errorf("ForEachRecord: %v", err)
+	}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("ForEachRecord: long records were not read correctly")
+	}
+}
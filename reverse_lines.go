@@ -0,0 +1,157 @@
+package jrutil
+
+import (
+	"bytes"
+	"io"
+	"iter"
+	"os"
+	"slices"
+)
+
+// reverseLineBlockSize is the number of bytes ReverseLineReader reads
+// at a time.
+const reverseLineBlockSize = 64 * 1024
+
+// ReverseLineReader reads lines of text backwards starting with the
+// last line.  See [NewReverseLineReader()].
+type ReverseLineReader struct {
+	rs        io.ReadSeeker
+	pos       int64  // offset in rs of the start of buf
+	buf       []byte // unread data that has been loaded
+	blockSize int
+}
+
+// NewReverseLineReader returns a new ReverseLineReader that reads the
+// lines of text in rs from last to first without loading all of rs
+// into memory.  The text is read in fixed-size blocks starting at the
+// end of rs.  DOS, Mac, and UNIX EOL sequences are recognized exactly
+// as they are by [ReadLine()] even when they span blocks, so the
+// lines are the same as if they had been read forwards.
+func NewReverseLineReader(rs io.ReadSeeker) (*ReverseLineReader, error) {
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	return &ReverseLineReader{
+		rs:        rs,
+		pos:       size,
+		blockSize: reverseLineBlockSize,
+	}, nil
+}
+
+// ReadLine returns the previous line of text with its EOL sequence
+// still attached.  Call [jrutil.StripEOL()] to remove the EOL
+// sequence.  After the first line has been returned, io.EOF is
+// returned.
+func (rr *ReverseLineReader) ReadLine() (string, error) {
+
+	// Make sure the entire EOL of the line is loaded.
+	for len(rr.buf) < 2 && rr.pos > 0 {
+		err := rr.load()
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(rr.buf) == 0 {
+		return "", io.EOF
+	}
+
+	// Get the length of the EOL at the end of the line.
+	eol := len(rr.buf) - len(StripEOLBytes(rr.buf))
+
+	// The line starts just after the previous EOL which is found by
+	// searching backwards, loading more data as needed.
+	for {
+		i := bytes.LastIndexAny(rr.buf[:len(rr.buf)-eol], "\r\n")
+		if i >= 0 {
+			line := string(rr.buf[i+1:])
+			rr.buf = rr.buf[:i+1]
+			return line, nil
+		}
+		if rr.pos == 0 {
+			line := string(rr.buf)
+			rr.buf = rr.buf[:0]
+			return line, nil
+		}
+		err := rr.load()
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// load reads the block just before the data that has been loaded.
+func (rr *ReverseLineReader) load() error {
+	n := min(int64(rr.blockSize), rr.pos)
+	pos := rr.pos - n
+	_, err := rr.rs.Seek(pos, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	block := make([]byte, int(n)+len(rr.buf))
+	_, err = io.ReadFull(rr.rs, block[:n])
+	if err != nil {
+		return err
+	}
+	copy(block[n:], rr.buf)
+	rr.buf = block
+	rr.pos = pos
+	return nil
+}
+
+// ReverseLines returns an iterator over the lines of text in rs from
+// last to first.  See [NewReverseLineReader()] for details and
+// [Lines()] for how the iterator handles errors.
+func ReverseLines(rs io.ReadSeeker, stripEOL bool) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		rr, err := NewReverseLineReader(rs)
+		if err != nil {
+			yield("", err)
+			return
+		}
+		for {
+			line, err := rr.ReadLine()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield("", err)
+				return
+			}
+			if stripEOL {
+				line = StripEOL(line)
+			}
+			if !yield(line, nil) {
+				return
+			}
+		}
+	}
+}
+
+// TailLines returns the last n lines of the file at path in their
+// original order with their EOL sequences stripped.  Only the end of
+// the file is read no matter how large the file is.
+func TailLines(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var result []string
+	if n <= 0 {
+		return result, nil
+	}
+	for line, err := range ReverseLines(f, true) {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, line)
+		if len(result) == n {
+			break
+		}
+	}
+	slices.Reverse(result)
+
+	return result, nil
+}
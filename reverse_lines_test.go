@@ -0,0 +1,119 @@
+package jrutil
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReverseLineReader(t *testing.T) {
+	data := []string{
+		"",
+		"\r",
+		"\n",
+		"\r\n",
+		"\n\r",
+		"\r\r\n",
+		"\n\n",
+		"foo",
+		"foo\r",
+		"foo\n",
+		"foo\r\n",
+		"foo\rbar",
+		"foo\nbar",
+		"foo\r\nbar",
+		"foo\rbar\nbaz\r\n",
+		"foo\r\rbar\n\nbaz\r\n\r\n",
+		"\r\nfoo\r\n\rbar\n\r\n",
+	}
+
+	for _, text := range data {
+
+		// Read the lines forwards.
+		expected := []string{}
+		lr := NewLineReader(strings.NewReader(text))
+		for {
+			line, err := lr.ReadLine()
+			if line != "" {
+				expected = append(expected, line)
+			}
+			if err != nil {
+				break
+			}
+		}
+		slices.Reverse(expected)
+
+		// Read the lines backwards with various block sizes so EOLs
+		// span blocks.
+		for _, blockSize := range []int{1, 2, 3, 5, reverseLineBlockSize} {
+			rr, err := NewReverseLineReader(strings.NewReader(text))
+			if err != nil {
+				t.Fatalf("NewReverseLineReader: %v", err)
+			}
+			rr.blockSize = blockSize
+			actual := []string{}
+			for {
+				line, err := rr.ReadLine()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("ReadLine: %v", err)
+				}
+				actual = append(actual, line)
+			}
+			if !slices.Equal(actual, expected) {
+				t.Errorf("ReverseLineReader(%q, %v): expected=%q  actual=%q",
+					text, blockSize, expected, actual)
+			}
+		}
+	}
+}
+
+func TestReverseLines(t *testing.T) {
+	var actual []string
+	r := strings.NewReader("foo\rbar\nbaz\r\n")
+	for line, err := range ReverseLines(r, true) {
+		if err != nil {
+			t.Fatalf("ReverseLines: %v", err)
+		}
+		actual = append(actual, line)
+	}
+	expected := []string{"baz", "bar", "foo"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("ReverseLines: expected=%q  actual=%q", expected, actual)
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tail.txt")
+	err := os.WriteFile(path, []byte("one\ntwo\r\nthree\rfour\nfive"), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	data := []struct {
+		n        int
+		expected []string
+	}{
+		{n: 0, expected: []string{}},
+		{n: 1, expected: []string{"five"}},
+		{n: 3, expected: []string{"three", "four", "five"}},
+		{n: 10, expected: []string{"one", "two", "three", "four", "five"}},
+	}
+
+	for _, d := range data {
+		actual, err := TailLines(path, d.n)
+		if err != nil {
+			t.Errorf("TailLines(%v): %v", d.n, err)
+			continue
+		}
+		if !slices.Equal(actual, d.expected) {
+			t.Errorf("TailLines(%v): expected=%q  actual=%q",
+				d.n, d.expected, actual)
+		}
+	}
+}
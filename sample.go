@@ -0,0 +1,143 @@
+package jrutil
+
+import (
+	"cmp"
+	"container/heap"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// sampledLine is a line that has been selected for a sample along
+// with its position in the input so the sample can be returned in
+// input order.
+type sampledLine struct {
+	index uint64
+	key   float64
+	line  string
+}
+
+// sortSample returns the lines of the sample in input order.
+func sortSample(sample []sampledLine) []string {
+	slices.SortFunc(sample, func(x, y sampledLine) int {
+		return cmp.Compare(x.index, y.index)
+	})
+	return Map(sample, func(s sampledLine) string { return s.line })
+}
+
+// SampleLines uses reservoir sampling to return a uniformly random
+// sample of k lines from r without holding more than k lines in
+// memory.  If r has k or fewer lines, all of them are returned, and if
+// k is zero or negative, an empty sample is returned.  The lines are
+// returned in the same order as they appear in r.  The stripEOL and
+// strict parameters have the same meaning as they do for
+// [ForEachLine()].  For reproducible samples, pass in a rand.Rand with
+// a fixed seed.  Otherwise, use [NewRand()].
+func SampleLines(
+	r io.Reader,
+	stripEOL bool,
+	strict bool,
+	k int,
+	rng *rand.Rand,
+) ([]string, error) {
+	var i uint64
+	sample := make([]sampledLine, 0, max(k, 0))
+
+	err := ForEachLine(r, stripEOL, strict, func(line string) (bool, error) {
+		if len(sample) < k {
+			sample = append(sample, sampledLine{index: i, line: line})
+		} else if j := rng.Uint64N(i + 1); k > 0 && j < uint64(k) {
+			sample[j] = sampledLine{index: i, line: line}
+		}
+		i++
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sortSample(sample), nil
+}
+
+// sampleHeap is a min-heap of sampled lines ordered by key.
+type sampleHeap []sampledLine
+
+func (h sampleHeap) Len() int           { return len(h) }
+func (h sampleHeap) Less(i, j int) bool { return h[i].key < h[j].key }
+func (h sampleHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *sampleHeap) Push(x any)        { *h = append(*h, x.(sampledLine)) }
+func (h *sampleHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// WeightedSampleLines is similar to [SampleLines()] except the
+// probability of selecting each line is proportional to its weight as
+// returned by the weight function.  Lines with a weight that is not
+// positive are never selected.  This uses the A-Res algorithm of
+// Efraimidis and Spirakis.
+func WeightedSampleLines(
+	r io.Reader,
+	stripEOL bool,
+	strict bool,
+	k int,
+	weight func(string) float64,
+	rng *rand.Rand,
+) ([]string, error) {
+	var i uint64
+	sample := &sampleHeap{}
+
+	err := ForEachLine(r, stripEOL, strict, func(line string) (bool, error) {
+		defer func() { i++ }()
+
+		// Skip lines that can never be selected.
+		w := weight(line)
+		if k <= 0 || !(w > 0) {
+			return true, nil
+		}
+
+		// The key is u^(1/w) which is computed in log space to avoid
+		// underflow.  The k lines with the largest keys are kept.
+		key := math.Log(1-rng.Float64()) / w
+		if sample.Len() < k {
+			heap.Push(sample, sampledLine{index: i, key: key, line: line})
+		} else if key > (*sample)[0].key {
+			(*sample)[0] = sampledLine{index: i, key: key, line: line}
+			heap.Fix(sample, 0)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sortSample(*sample), nil
+}
+
+// BernoulliSampleLines returns each line of r with probability p
+// independently of the other lines, so the size of the sample is only
+// approximately p times the number of lines.  The lines are returned
+// in the same order as they appear in r.  See [SampleLines()] for the
+// remaining parameters.
+func BernoulliSampleLines(
+	r io.Reader,
+	stripEOL bool,
+	strict bool,
+	p float64,
+	rng *rand.Rand,
+) ([]string, error) {
+	var sample []string
+	err := ForEachLine(r, stripEOL, strict, func(line string) (bool, error) {
+		if rng.Float64() < p {
+			sample = append(sample, line)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sample, nil
+}
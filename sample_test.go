@@ -0,0 +1,151 @@
+package jrutil
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// makeSampleText returns count numbered lines.
+func makeSampleText(count int) (string, []string) {
+	var lines []string
+	for i := 0; i < count; i++ {
+		lines = append(lines, fmt.Sprintf("%v", i))
+	}
+	return strings.Join(lines, "\n") + "\n", lines
+}
+
+// isOrderedSubset returns true if every element of xs is in ys in
+// the same order.
+func isOrderedSubset(xs, ys []string) bool {
+	j := 0
+	for _, x := range xs {
+		for j < len(ys) && ys[j] != x {
+			j++
+		}
+		if j == len(ys) {
+			return false
+		}
+		j++
+	}
+	return true
+}
+
+func TestSampleLines(t *testing.T) {
+	text, lines := makeSampleText(100)
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for _, k := range []int{-1, 0, 1, 10, 100, 200} {
+		sample, err := SampleLines(strings.NewReader(text), true, false, k, rng)
+		if err != nil {
+			t.Fatalf("SampleLines: %v", err)
+		}
+		if len(sample) != max(min(k, len(lines)), 0) {
+			t.Errorf("SampleLines(%v): expected_length=%v  actual_length=%v",
+				k, max(min(k, len(lines)), 0), len(sample))
+		}
+		if !isOrderedSubset(sample, lines) {
+			t.Errorf("SampleLines(%v): %q is not an ordered subset", k, sample)
+		}
+	}
+
+	// The same seed gives the same sample.
+	s1, _ := SampleLines(strings.NewReader(text), true, false, 5, rand.New(rand.NewPCG(3, 4)))
+	s2, _ := SampleLines(strings.NewReader(text), true, false, 5, rand.New(rand.NewPCG(3, 4)))
+	if !slices.Equal(s1, s2) {
+		t.Errorf("SampleLines: same seed gave %q and %q", s1, s2)
+	}
+}
+
+// TestSampleLinesUniform makes sure each line is equally likely to
+// be selected.
+func TestSampleLinesUniform(t *testing.T) {
+	text, lines := makeSampleText(10)
+	rng := rand.New(rand.NewPCG(5, 6))
+	counts := map[string]int{}
+	const runs = 20000
+	for i := 0; i < runs; i++ {
+		sample, err := SampleLines(strings.NewReader(text), true, true, 2, rng)
+		if err != nil {
+			t.Fatalf("SampleLines: %v", err)
+		}
+		for _, line := range sample {
+			counts[line]++
+		}
+	}
+
+	// Each line should be selected about 2/10 of the time.
+	for _, line := range lines {
+		if counts[line] < 3600 || counts[line] > 4400 {
+			t.Errorf("SampleLines: line %q selected %v times", line, counts[line])
+		}
+	}
+}
+
+func TestWeightedSampleLines(t *testing.T) {
+	text, lines := makeSampleText(100)
+	rng := rand.New(rand.NewPCG(7, 8))
+
+	// Only even lines have weight, and line "50" is very heavy.
+	weight := func(line string) float64 {
+		n, _ := strconv.Atoi(line)
+		switch {
+		case n == 50:
+			return 1e9
+		case n%2 != 0:
+			return 0
+		default:
+			return 1
+		}
+	}
+	for i := 0; i < 100; i++ {
+		sample, err := WeightedSampleLines(strings.NewReader(text), true, false,
+			5, weight, rng)
+		if err != nil {
+			t.Fatalf("WeightedSampleLines: %v", err)
+		}
+		if len(sample) != 5 || !isOrderedSubset(sample, lines) {
+			t.Fatalf("WeightedSampleLines: unexpected sample %q", sample)
+		}
+		if !slices.Contains(sample, "50") {
+			t.Errorf("WeightedSampleLines: heavy line missing from %q", sample)
+		}
+		for _, line := range sample {
+			if weight(line) == 0 {
+				t.Errorf("WeightedSampleLines: zero weight line %q selected", line)
+			}
+		}
+	}
+}
+
+func TestBernoulliSampleLines(t *testing.T) {
+	text, lines := makeSampleText(10000)
+	rng := rand.New(rand.NewPCG(9, 10))
+
+	data := []struct {
+		p      float64
+		minLen int
+		maxLen int
+	}{
+		{p: 0, minLen: 0, maxLen: 0},
+		{p: 0.1, minLen: 900, maxLen: 1100},
+		{p: 1, minLen: 10000, maxLen: 10000},
+	}
+	for _, d := range data {
+		sample, err := BernoulliSampleLines(strings.NewReader(text), true, false,
+			d.p, rng)
+		if err != nil {
+			t.Fatalf("BernoulliSampleLines: %v", err)
+		}
+		if len(sample) < d.minLen || len(sample) > d.maxLen {
+			t.Errorf("BernoulliSampleLines(%v): unexpected length %v",
+				d.p, len(sample))
+		}
+		if !isOrderedSubset(sample, lines) {
+			t.Errorf("BernoulliSampleLines(%v): not an ordered subset", d.p)
+		}
+	}
+}
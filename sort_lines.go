@@ -0,0 +1,401 @@
+package jrutil
+
+import (
+	"bufio"
+	"cmp"
+	"container/heap"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultSortMemoryBudget is the default value for
+// SortOptions.MemoryBudget.
+const defaultSortMemoryBudget = 64 * 1024 * 1024
+
+// sortItemOverhead approximates the memory used by each line beyond
+// the bytes of the line itself.
+const sortItemOverhead = 64
+
+// SortOptions holds the options for [SortLines()].
+type SortOptions struct {
+
+	// IsLessThan compares the keys of two lines.  If it is nil, keys
+	// are compared as strings or as numbers if Numeric is true.
+	IsLessThan func(x, y string) bool
+
+	// Numeric causes keys to be compared as floating point numbers.
+	// Keys that are not numbers are treated as zero.
+	Numeric bool
+
+	// KeyField is the 1-based index of the field to use as the key.
+	// If it is zero, the entire line is the key.  Lines that do not
+	// have the field have an empty key.
+	KeyField int
+
+	// FieldSeparator specifies how lines are split into fields when
+	// KeyField is set.  The zero value splits on runs of whitespace.
+	FieldSeparator FieldSeparator
+
+	// Unique causes only the first of the lines with equal keys to be
+	// written.
+	Unique bool
+
+	// MemoryBudget is the approximate number of bytes of lines to
+	// sort in memory before spilling them to a temporary file.  If it
+	// is zero, 64 MiB is used.
+	MemoryBudget int64
+
+	// TempDir is the directory for temporary files.  If it is empty,
+	// the default directory for temporary files is used.
+	TempDir string
+
+	// Strict has the same meaning as the corresponding parameter of
+	// ForEachLine().
+	Strict bool
+}
+
+// sortItem is a line and its key.
+type sortItem struct {
+	line string
+	key  string
+	num  float64
+}
+
+// lineSorter holds the state of SortLines().
+type lineSorter struct {
+	opts   SortOptions
+	fields FieldOptions
+	dir    string
+	chunks []string // names of the sorted temporary files
+}
+
+// SortLines sorts the lines of text read from in and writes them to
+// out.  It reads the lines using [ForEachLine()] with their EOL
+// sequences stripped and writes them with UNIX EOLs.  The sort is
+// stable so lines with equal keys are written in input order.  If
+// opts is nil, the default options are used.
+//
+// Input that does not fit in the memory budget is sorted in chunks
+// which are written to temporary files and then merged, so the input
+// can be much larger than the available memory.  The temporary files
+// are removed before returning.
+func SortLines(in io.Reader, out io.Writer, opts *SortOptions) (err error) {
+	s := &lineSorter{}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.MemoryBudget <= 0 {
+		s.opts.MemoryBudget = defaultSortMemoryBudget
+	}
+	s.fields.Separator = s.opts.FieldSeparator
+	defer func() {
+		if s.dir != "" {
+			removeErr := os.RemoveAll(s.dir)
+			if err == nil {
+				err = removeErr
+			}
+		}
+	}()
+
+	// Read the lines spilling sorted chunks to temporary files when
+	// the memory budget is exceeded.
+	var chunk []sortItem
+	var size int64
+	err = ForEachLine(in, true, s.opts.Strict, func(line string) (bool, error) {
+		chunk = append(chunk, s.makeItem(line))
+		size += int64(len(line)) + sortItemOverhead
+		if size < s.opts.MemoryBudget {
+			return true, nil
+		}
+		err := s.spill(chunk)
+		clear(chunk)
+		chunk = chunk[:0]
+		size = 0
+		return true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	// If everything fit in memory, just write the sorted lines.
+	w := bufio.NewWriter(out)
+	if len(s.chunks) == 0 {
+		err = s.write(w, s.sort(chunk))
+		if err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	// Otherwise, spill the last chunk and merge the chunks.
+	if len(chunk) > 0 {
+		err = s.spill(chunk)
+		if err != nil {
+			return err
+		}
+	}
+	err = s.merge(w)
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// makeItem returns the line along with its key.
+func (s *lineSorter) makeItem(line string) sortItem {
+	item := sortItem{line: line, key: line}
+	if s.opts.KeyField > 0 {
+		fields, _ := splitFields(line, &s.fields, nil)
+		item.key = ""
+		if s.opts.KeyField <= len(fields) {
+			item.key = fields[s.opts.KeyField-1]
+		}
+	}
+	if s.opts.Numeric {
+		item.num, _ = strconv.ParseFloat(strings.TrimSpace(item.key), 64)
+	}
+	return item
+}
+
+// compare compares the keys of two lines.
+func (s *lineSorter) compare(x, y *sortItem) int {
+	switch {
+	case s.opts.IsLessThan != nil:
+		if s.opts.IsLessThan(x.key, y.key) {
+			return -1
+		}
+		if s.opts.IsLessThan(y.key, x.key) {
+			return 1
+		}
+		return 0
+	case s.opts.Numeric:
+		return cmp.Compare(x.num, y.num)
+	default:
+		return strings.Compare(x.key, y.key)
+	}
+}
+
+// sort sorts the items removing duplicates if necessary.
+func (s *lineSorter) sort(items []sortItem) []sortItem {
+	slices.SortStableFunc(items, func(x, y sortItem) int {
+		return s.compare(&x, &y)
+	})
+	if s.opts.Unique {
+		items = slices.CompactFunc(items, func(x, y sortItem) bool {
+			return s.compare(&x, &y) == 0
+		})
+	}
+	return items
+}
+
+// write writes the lines of the items.
+func (s *lineSorter) write(w *bufio.Writer, items []sortItem) error {
+	for i := range items {
+		err := writeSortedLine(w, items[i].line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSortedLine writes the line followed by a UNIX EOL.
+func writeSortedLine(w *bufio.Writer, line string) error {
+	_, err := w.WriteString(line)
+	if err != nil {
+		return err
+	}
+	return w.WriteByte('\n')
+}
+
+// spill sorts the items and writes them to a new temporary file.
+func (s *lineSorter) spill(items []sortItem) (err error) {
+
+	// Create the directory for the temporary files.
+	if s.dir == "" {
+		s.dir, err = os.MkdirTemp(s.opts.TempDir, "jrutil-sort-")
+		if err != nil {
+			return err
+		}
+	}
+
+	// Write the sorted chunk.
+	f, err := os.CreateTemp(s.dir, "chunk-")
+	if err != nil {
+		return err
+	}
+	s.chunks = append(s.chunks, f.Name())
+	defer func() {
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+	w := bufio.NewWriter(f)
+	err = s.write(w, s.sort(items))
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// sortMaxMergeFiles is the maximum number of temporary files that are
+// merged at once which keeps SortLines() well below the usual limit
+// on open files.
+const sortMaxMergeFiles = 64
+
+// mergeSource is a sorted temporary file being merged.
+type mergeSource struct {
+	item  sortItem
+	index int // index of the chunk which keeps the merge stable
+	r     *bufio.Reader
+}
+
+// next reads the next line from the source.  It returns false when
+// the source is empty.
+func (m *mergeSource) next(s *lineSorter) (bool, error) {
+	line, err := m.r.ReadString('\n')
+	if line == "" {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, err
+	}
+	m.item = s.makeItem(strings.TrimSuffix(line, "\n"))
+	return true, nil
+}
+
+// mergeHeap is a min-heap of merge sources.
+type mergeHeap struct {
+	s       *lineSorter
+	sources []*mergeSource
+}
+
+func (h *mergeHeap) Len() int { return len(h.sources) }
+func (h *mergeHeap) Less(i, j int) bool {
+	c := h.s.compare(&h.sources[i].item, &h.sources[j].item)
+	if c == 0 {
+		return h.sources[i].index < h.sources[j].index
+	}
+	return c < 0
+}
+func (h *mergeHeap) Swap(i, j int) {
+	h.sources[i], h.sources[j] = h.sources[j], h.sources[i]
+}
+func (h *mergeHeap) Push(x any) { h.sources = append(h.sources, x.(*mergeSource)) }
+func (h *mergeHeap) Pop() any {
+	x := h.sources[len(h.sources)-1]
+	h.sources = h.sources[:len(h.sources)-1]
+	return x
+}
+
+// merge merges the sorted temporary files.  To limit the number of
+// open files, at most sortMaxMergeFiles are merged at once which can
+// require more than one pass.
+func (s *lineSorter) merge(w *bufio.Writer) error {
+	chunks := s.chunks
+	for len(chunks) > sortMaxMergeFiles {
+
+		// Merge consecutive groups of chunks into new chunks.
+		// Keeping the groups in order keeps the sort stable.
+		var merged []string
+		for i := 0; i < len(chunks); i += sortMaxMergeFiles {
+			group := chunks[i:min(i+sortMaxMergeFiles, len(chunks))]
+			if len(group) == 1 {
+				merged = append(merged, group[0])
+				continue
+			}
+			name, err := s.mergeToFile(group)
+			if err != nil {
+				return err
+			}
+			merged = append(merged, name)
+		}
+		chunks = merged
+	}
+	return s.mergeFiles(w, chunks)
+}
+
+// mergeToFile merges the sorted temporary files into a new temporary
+// file and returns its name.  The merged files are removed to save
+// space.
+func (s *lineSorter) mergeToFile(names []string) (name string, err error) {
+	f, err := os.CreateTemp(s.dir, "merge-")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		closeErr := f.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+	w := bufio.NewWriter(f)
+	err = s.mergeFiles(w, names)
+	if err != nil {
+		return "", err
+	}
+	err = w.Flush()
+	if err != nil {
+		return "", err
+	}
+	for _, merged := range names {
+		err = os.Remove(merged)
+		if err != nil {
+			return "", err
+		}
+	}
+	return f.Name(), nil
+}
+
+// mergeFiles performs a k-way merge of the sorted temporary files.
+func (s *lineSorter) mergeFiles(w *bufio.Writer, names []string) error {
+
+	// Open each chunk.
+	h := &mergeHeap{s: s}
+	for i, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		m := &mergeSource{index: i, r: bufio.NewReader(f)}
+		ok, err := m.next(s)
+		if err != nil {
+			return err
+		}
+		if ok {
+			h.sources = append(h.sources, m)
+		}
+	}
+	heap.Init(h)
+
+	// Repeatedly write the smallest line.
+	var prev sortItem
+	first := true
+	for h.Len() > 0 {
+		m := h.sources[0]
+		if first || !s.opts.Unique || s.compare(&prev, &m.item) != 0 {
+			err := writeSortedLine(w, m.item.line)
+			if err != nil {
+				return err
+			}
+			prev = m.item
+			first = false
+		}
+		ok, err := m.next(s)
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package jrutil
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// sortLinesString sorts the text with SortLines() and returns the
+// sorted lines.
+func sortLinesString(t *testing.T, text string, opts *SortOptions) []string {
+	t.Helper()
+	var b strings.Builder
+	err := SortLines(strings.NewReader(text), &b, opts)
+	if err != nil {
+		t.Fatalf("SortLines: %v", err)
+	}
+	if b.Len() == 0 {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+}
+
+func TestSortLines(t *testing.T) {
+	data := []struct {
+		text     string
+		opts     *SortOptions
+		expected []string
+	}{
+		{
+			text:     "",
+			opts:     nil,
+			expected: []string{},
+		},
+		{
+			text:     "pear\r\napple\nfig\nbanana",
+			opts:     nil,
+			expected: []string{"apple", "banana", "fig", "pear"},
+		},
+		{
+			text:     "b\na\nb\nc\na\n",
+			opts:     &SortOptions{Unique: true},
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			text:     "10\n9\n-1.5\nx\n100\n",
+			opts:     &SortOptions{Numeric: true},
+			expected: []string{"-1.5", "x", "9", "10", "100"},
+		},
+		{
+			text: "a 3\nb 1\nc 2\nd 1\n",
+			opts: &SortOptions{
+				KeyField: 2,
+				Numeric:  true,
+			},
+			expected: []string{"b 1", "d 1", "c 2", "a 3"},
+		},
+		{
+			text: "x,b\ny,a\nz,b\n",
+			opts: &SortOptions{
+				KeyField:       2,
+				FieldSeparator: CharSeparator(','),
+				Unique:         true,
+			},
+			expected: []string{"y,a", "x,b"},
+		},
+		{
+			text: "a\nc\nb\n",
+			opts: &SortOptions{
+				IsLessThan: func(x, y string) bool { return x > y },
+			},
+			expected: []string{"c", "b", "a"},
+		},
+	}
+
+	for _, d := range data {
+		actual := sortLinesString(t, d.text, d.opts)
+		if !slices.Equal(actual, d.expected) {
+			t.Errorf("SortLines(%q): expected=%q  actual=%q",
+				d.text, d.expected, actual)
+		}
+	}
+}
+
+// TestSortLinesExternal tests sorting with a memory budget that is
+// small enough to force many temporary files to be merged.
+func TestSortLinesExternal(t *testing.T) {
+	rng := rand.New(rand.NewPCG(11, 12))
+	var lines []string
+	for i := 0; i < 5000; i++ {
+		lines = append(lines, fmt.Sprintf("%v %v", rng.IntN(1000), i))
+	}
+	text := strings.Join(lines, "\n") + "\n"
+
+	// A budget of one byte puts each line in its own chunk which
+	// requires more than one merge pass.
+	for _, budget := range []int64{4096, 1} {
+		dir := t.TempDir()
+
+		// Sort on the first field so there are many equal keys, and
+		// check that the sort is stable.
+		opts := &SortOptions{
+			KeyField:     1,
+			Numeric:      true,
+			MemoryBudget: budget,
+			TempDir:      dir,
+		}
+		actual := sortLinesString(t, text, opts)
+		expected := slices.Clone(lines)
+		slices.SortStableFunc(expected, func(x, y string) int {
+			var xk, yk int
+			fmt.Sscan(x, &xk)
+			fmt.Sscan(y, &yk)
+			return xk - yk
+		})
+		if !slices.Equal(actual, expected) {
+			t.Errorf("SortLines(budget=%v): external sort does not match in-memory sort",
+				budget)
+		}
+
+		// Unique across chunks.
+		opts.Unique = true
+		actual = sortLinesString(t, text, opts)
+		expected = slices.CompactFunc(expected, func(x, y string) bool {
+			return strings.Fields(x)[0] == strings.Fields(y)[0]
+		})
+		if !slices.Equal(actual, expected) {
+			t.Errorf("SortLines(budget=%v): external unique sort does not match in-memory sort",
+				budget)
+		}
+
+		// The temporary files should be gone.
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("ReadDir: %v", err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("SortLines(budget=%v): temporary files were left behind: %v",
+				budget, entries)
+		}
+	}
+}
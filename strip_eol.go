@@ -20,3 +20,21 @@ func StripEOL(line string) string {
 	}
 	return line
 }
+
+// StripEOLBytes is the same as [StripEOL()] except it operates on a
+// slice of bytes.  The returned slice shares the same underlying
+// array as line.
+func StripEOLBytes(line []byte) []byte {
+	count := len(line)
+	if count >= 2 {
+		if line[count-2] == '\r' && line[count-1] == '\n' {
+			return line[:count-2]
+		}
+	}
+	if count >= 1 {
+		if line[count-1] == '\r' || line[count-1] == '\n' {
+			return line[:count-1]
+		}
+	}
+	return line
+}
@@ -31,3 +31,28 @@ func TestStripEOL(t *testing.T) {
 		}
 	}
 }
+
+func TestStripEOLBytes(t *testing.T) {
+	data := []struct {
+		line     string
+		expected string
+	}{
+		{line: "", expected: ""},
+		{line: "\r", expected: ""},
+		{line: "\n", expected: ""},
+		{line: "\r\n", expected: ""},
+		{line: "foo", expected: "foo"},
+		{line: "foo\r", expected: "foo"},
+		{line: "foo\n", expected: "foo"},
+		{line: "foo\r\n", expected: "foo"},
+		{line: "foo\n\r", expected: "foo\n"},
+	}
+
+	for _, d := range data {
+		actual := string(StripEOLBytes([]byte(d.line)))
+		if actual != d.expected {
+			t.Errorf("StripEOLBytes(%q): expected=%q  actual=%q",
+				d.line, d.expected, actual)
+		}
+	}
+}
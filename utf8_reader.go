@@ -0,0 +1,270 @@
+package jrutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// utf8ReaderChunkSize is the number of bytes UTF8Reader tries to
+// decode at a time.
+const utf8ReaderChunkSize = 4096
+
+// TextEncoding identifies the encoding of the input to a UTF8Reader.
+type TextEncoding int
+
+const (
+	// EncodingUTF8 is UTF-8 with or without a byte order mark.
+	EncodingUTF8 TextEncoding = iota
+
+	// EncodingUTF16LE is little-endian UTF-16.
+	EncodingUTF16LE
+
+	// EncodingUTF16BE is big-endian UTF-16.
+	EncodingUTF16BE
+)
+
+// String returns the name of the encoding.
+func (e TextEncoding) String() string {
+	switch e {
+	case EncodingUTF8:
+		return "UTF-8"
+	case EncodingUTF16LE:
+		return "UTF-16LE"
+	case EncodingUTF16BE:
+		return "UTF-16BE"
+	default:
+		return "Unknown"
+	}
+}
+
+// InvalidTextPolicy selects what a UTF8Reader does when it finds an
+// invalid sequence in its input.
+type InvalidTextPolicy int
+
+const (
+	// ReplaceInvalidText replaces each invalid sequence with the
+	// Unicode replacement character, U+FFFD.
+	ReplaceInvalidText InvalidTextPolicy = iota
+
+	// RejectInvalidText causes the read to fail with an
+	// *InvalidTextError.
+	RejectInvalidText
+)
+
+// InvalidTextError is the error returned by a UTF8Reader using the
+// RejectInvalidText policy when it finds an invalid sequence.
+type InvalidTextError struct {
+
+	// Encoding is the encoding of the input.
+	Encoding TextEncoding
+
+	// Offset is the byte offset in the input of the start of the
+	// invalid sequence.
+	Offset int64
+}
+
+// Error returns the error message.
+func (e *InvalidTextError) Error() string {
+	return fmt.Sprintf("invalid %v at offset %v", e.Encoding, e.Offset)
+}
+
+// UTF8Reader is an io.Reader that sniffs the byte order mark (BOM) at
+// the start of its input and transcodes UTF-16 to UTF-8 so the output
+// can be read by the line readers in this package which assume UTF-8.
+// See [NewUTF8Reader()].
+type UTF8Reader struct {
+	br      *bufio.Reader
+	policy  InvalidTextPolicy
+	sniffed bool
+	enc     TextEncoding
+	offset  int64   // offset in the input of the next byte to decode
+	unit    *uint16 // UTF-16 code unit read ahead while decoding
+	out     []byte  // decoded output that has not been read yet
+	err     error   // sticky error
+}
+
+// NewUTF8Reader returns a new UTF8Reader that reads from r.  If the
+// input starts with a UTF-16LE or UTF-16BE BOM, the input is
+// transcoded to UTF-8.  If the input starts with a UTF-8 BOM, the BOM
+// is removed.  Otherwise, the input is assumed to be UTF-8.  In all
+// cases, the BOM is not part of the output, and invalid sequences are
+// handled according to policy.  To read lines from a file that might
+// have been written by a Windows tool, do the following:
+//
+//	err := jrutil.ForEachLine(jrutil.NewUTF8Reader(f, jrutil.ReplaceInvalidText),
+//		true, false, fn)
+func NewUTF8Reader(r io.Reader, policy InvalidTextPolicy) *UTF8Reader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &UTF8Reader{
+		br:     br,
+		policy: policy,
+	}
+}
+
+// Encoding returns the encoding of the input as determined by the
+// BOM.  The result is only meaningful after the first call to Read().
+func (u *UTF8Reader) Encoding() TextEncoding {
+	return u.enc
+}
+
+// Read reads UTF-8 encoded text into p.
+func (u *UTF8Reader) Read(p []byte) (int, error) {
+
+	// Sniff the BOM.
+	if !u.sniffed {
+		u.sniffed = true
+		u.sniff()
+	}
+
+	// Decode more of the input if necessary.
+	for len(u.out) == 0 && u.err == nil {
+		if u.enc == EncodingUTF8 {
+			u.decodeUTF8()
+		} else {
+			u.decodeUTF16()
+		}
+	}
+
+	// Return the decoded output before any error.
+	if len(u.out) > 0 {
+		n := copy(p, u.out)
+		u.out = u.out[n:]
+		return n, nil
+	}
+
+	return 0, u.err
+}
+
+// sniff determines the encoding of the input from its BOM and then
+// discards the BOM.
+func (u *UTF8Reader) sniff() {
+	bom, _ := u.br.Peek(3)
+	switch {
+	case len(bom) >= 3 && bom[0] == 0xEF && bom[1] == 0xBB && bom[2] == 0xBF:
+		u.enc = EncodingUTF8
+		u.offset = 3
+	case len(bom) >= 2 && bom[0] == 0xFF && bom[1] == 0xFE:
+		u.enc = EncodingUTF16LE
+		u.offset = 2
+	case len(bom) >= 2 && bom[0] == 0xFE && bom[1] == 0xFF:
+		u.enc = EncodingUTF16BE
+		u.offset = 2
+	default:
+		u.enc = EncodingUTF8
+		u.offset = 0
+	}
+	_, _ = u.br.Discard(int(u.offset))
+}
+
+// invalid handles an invalid sequence at the given offset according
+// to the policy.  It returns true if decoding should continue.
+func (u *UTF8Reader) invalid(offset int64) bool {
+	if u.policy == RejectInvalidText {
+		u.err = &InvalidTextError{Encoding: u.enc, Offset: offset}
+		return false
+	}
+	u.out = utf8.AppendRune(u.out, utf8.RuneError)
+	return true
+}
+
+// decodeUTF8 validates the next chunk of UTF-8 input.
+func (u *UTF8Reader) decodeUTF8() {
+	u.out = u.out[:0]
+	for len(u.out) < utf8ReaderChunkSize {
+		r, size, err := u.br.ReadRune()
+		if err != nil {
+			u.err = err
+			return
+		}
+		offset := u.offset
+		u.offset += int64(size)
+		if r == utf8.RuneError && size == 1 {
+			if !u.invalid(offset) {
+				return
+			}
+			continue
+		}
+		u.out = utf8.AppendRune(u.out, r)
+	}
+}
+
+// readUnit returns the next UTF-16 code unit.  If only one byte is
+// left in the input, io.ErrUnexpectedEOF is returned.
+func (u *UTF8Reader) readUnit() (uint16, error) {
+	if u.unit != nil {
+		unit := *u.unit
+		u.unit = nil
+		return unit, nil
+	}
+	var b [2]byte
+	n, err := io.ReadFull(u.br, b[:])
+	u.offset += int64(n)
+	if err != nil {
+		return 0, err
+	}
+	if u.enc == EncodingUTF16LE {
+		return uint16(b[0]) | uint16(b[1])<<8, nil
+	}
+	return uint16(b[0])<<8 | uint16(b[1]), nil
+}
+
+// decodeUTF16 transcodes the next chunk of UTF-16 input to UTF-8.
+func (u *UTF8Reader) decodeUTF16() {
+	u.out = u.out[:0]
+	for len(u.out) < utf8ReaderChunkSize {
+
+		// Get the next code unit.
+		unit, err := u.readUnit()
+		if err == io.ErrUnexpectedEOF {
+			if u.invalid(u.offset - 1) {
+				u.err = io.EOF
+			}
+			return
+		}
+		if err != nil {
+			u.err = err
+			return
+		}
+		offset := u.offset - 2
+
+		// Most code units are a rune by themselves.
+		if !utf16.IsSurrogate(rune(unit)) {
+			u.out = utf8.AppendRune(u.out, rune(unit))
+			continue
+		}
+
+		// Otherwise, this should be the first half of a surrogate
+		// pair.  If the second half is missing, hold on to whatever
+		// was read in its place so it is decoded on its own.
+		next, err := u.readUnit()
+		if err == nil {
+			r := utf16.DecodeRune(rune(unit), rune(next))
+			if r != utf8.RuneError {
+				u.out = utf8.AppendRune(u.out, r)
+				continue
+			}
+			u.unit = &next
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			u.err = err
+			return
+		}
+		if !u.invalid(offset) {
+			return
+		}
+
+		// Handle a single byte left at the end of the input.
+		if err == io.ErrUnexpectedEOF {
+			if u.invalid(u.offset - 1) {
+				u.err = io.EOF
+			}
+			return
+		}
+	}
+}
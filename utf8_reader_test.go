@@ -0,0 +1,169 @@
+package jrutil
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// encodeUTF16 encodes the string as UTF-16 with a BOM.
+func encodeUTF16(s string, bigEndian bool) string {
+	var b []byte
+	for _, r := range "\uFEFF" + s {
+		var units []uint16
+		if r >= 0x10000 {
+			r -= 0x10000
+			units = []uint16{uint16(0xD800 + (r >> 10)), uint16(0xDC00 + (r & 0x3FF))}
+		} else {
+			units = []uint16{uint16(r)}
+		}
+		for _, unit := range units {
+			if bigEndian {
+				b = append(b, byte(unit>>8), byte(unit))
+			} else {
+				b = append(b, byte(unit), byte(unit>>8))
+			}
+		}
+	}
+	return string(b)
+}
+
+func TestUTF8Reader(t *testing.T) {
+	data := []struct {
+		text     string
+		encoding TextEncoding
+		expected string
+	}{
+		{
+			text:     "",
+			encoding: EncodingUTF8,
+			expected: "",
+		},
+		{
+			text:     "foo\nbar",
+			encoding: EncodingUTF8,
+			expected: "foo\nbar",
+		},
+		{
+			text:     "\xEF\xBB\xBFfoo\nbar",
+			encoding: EncodingUTF8,
+			expected: "foo\nbar",
+		},
+		{
+			text:     "f\xFFo\xC3",
+			encoding: EncodingUTF8,
+			expected: "f�o�",
+		},
+		{
+			text:     encodeUTF16("foo\r\nbär 😀\r\n", false),
+			encoding: EncodingUTF16LE,
+			expected: "foo\r\nbär 😀\r\n",
+		},
+		{
+			text:     encodeUTF16("foo\r\nbär 😀\r\n", true),
+			encoding: EncodingUTF16BE,
+			expected: "foo\r\nbär 😀\r\n",
+		},
+		{
+			// Unpaired high surrogate followed by "a".
+			text:     "\xFF\xFE\x00\xD8a\x00",
+			encoding: EncodingUTF16LE,
+			expected: "�a",
+		},
+		{
+			// Odd number of bytes.
+			text:     "\xFE\xFF\x00ab",
+			encoding: EncodingUTF16BE,
+			expected: "a�",
+		},
+	}
+
+	for _, oneByte := range []bool{false, true} {
+		for _, d := range data {
+			var r io.Reader = strings.NewReader(d.text)
+			if oneByte {
+				r = iotest.OneByteReader(r)
+			}
+			u := NewUTF8Reader(r, ReplaceInvalidText)
+			actual, err := io.ReadAll(u)
+			if err != nil {
+				t.Errorf("UTF8Reader(%q): %v", d.text, err)
+				continue
+			}
+			if string(actual) != d.expected {
+				t.Errorf("UTF8Reader(%q): expected=%q  actual=%q",
+					d.text, d.expected, actual)
+			}
+			if u.Encoding() != d.encoding {
+				t.Errorf("UTF8Reader(%q): expected_encoding=%v  actual_encoding=%v",
+					d.text, d.encoding, u.Encoding())
+			}
+		}
+	}
+}
+
+// TestUTF8ReaderReject tests that invalid sequences are reported with
+// their offsets when using RejectInvalidText.
+func TestUTF8ReaderReject(t *testing.T) {
+	data := []struct {
+		text     string
+		expected string
+		offset   int64
+	}{
+		{
+			text:     "foo\nb\xFFr\n",
+			expected: "foo\nb",
+			offset:   5,
+		},
+		{
+			text:     "\xEF\xBB\xBFfoo\x80",
+			expected: "foo",
+			offset:   6,
+		},
+		{
+			text:     "\xFF\xFEa\x00\x00\xDC",
+			expected: "a",
+			offset:   4,
+		},
+	}
+
+	for _, d := range data {
+		actual, err := io.ReadAll(NewUTF8Reader(strings.NewReader(d.text),
+			RejectInvalidText))
+		var textErr *InvalidTextError
+		if !errors.As(err, &textErr) {
+			t.Errorf("UTF8Reader(%q): expected *InvalidTextError  actual=%v",
+				d.text, err)
+			continue
+		}
+		if textErr.Offset != d.offset {
+			t.Errorf("UTF8Reader(%q): expected_offset=%v  actual_offset=%v",
+				d.text, d.offset, textErr.Offset)
+		}
+		if string(actual) != d.expected {
+			t.Errorf("UTF8Reader(%q): expected=%q  actual=%q",
+				d.text, d.expected, actual)
+		}
+	}
+}
+
+// TestUTF8ReaderLines tests reading lines from UTF-16 input.
+func TestUTF8ReaderLines(t *testing.T) {
+	var actual []string
+	r := NewUTF8Reader(strings.NewReader(encodeUTF16("foo\r\nbar\r\n", false)),
+		ReplaceInvalidText)
+	err := ForEachLine(r, true, true, func(line string) (bool, error) {
+		actual = append(actual, line)
+		return true, nil
+	})
+	if err != nil {
+		t.Errorf("ForEachLine: %v", err)
+	}
+	expected := []string{"foo", "bar"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("ForEachLine: expected=%q  actual=%q", expected, actual)
+	}
+}